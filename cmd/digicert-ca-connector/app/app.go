@@ -2,8 +2,11 @@
 package app
 
 import (
+	"fmt"
+
 	connector "github.com/venafi/digicert-ca-connector/internal/app/digicert-ca-connector"
 	"github.com/venafi/digicert-ca-connector/internal/app/service"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 	"github.com/venafi/digicert-ca-connector/internal/handler/web"
 
 	"go.uber.org/fx"
@@ -11,42 +14,117 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-// New ...
-func New() *fx.App {
+// New creates the application from the configuration, which is validated first
+func New(config *Config) *fx.App {
 	var logger *zap.Logger
 
 	app := fx.New(
 		fx.Provide(
+			config.components,
 			configureLogger,
 			web.ConfigureHTTPServers,
 			fx.Annotate(service.NewConnectionService, fx.As(new(connector.ConnectionService))),
 			fx.Annotate(service.NewOptionsService, fx.As(new(connector.OptionsService))),
-			fx.Annotate(service.NewCertificateService, fx.As(new(connector.CertificateService))),
+			fx.Annotate(newCertificateService, fx.As(new(connector.CertificateService))),
 			fx.Annotate(connector.NewWebhookService, fx.As(new(web.WebhookService))),
 		),
 		fx.Invoke(
+			configureRestClient,
+			tracing.Configure,
 			web.RegisterHandlers,
+			web.RegisterProbes,
 		),
 		fx.Populate(&logger),
 	)
 
-	logger.Info("CA connector starting")
+	if logger != nil {
+		logger.Info("CA connector starting")
+	}
 
 	return app
 }
 
-func configureLogger() (*zap.Logger, error) {
+// components splits the configuration into the parts the components depend on
+type components struct {
+	fx.Out
+
+	Server            web.ServerConfig
+	Log               LogConfig
+	PayloadEncryption web.PayloadEncryptionConfig
+	DigiCert          DigiCertConfig
+	RestClient        service.RestClientConfig
+	Cache             CacheConfig
+	Import            ImportConfig
+	Readiness         web.ProbeConfig
+	Tracing           tracing.Config
+}
+
+func (c *Config) components() (components, error) {
+	if err := c.Validate(); err != nil {
+		return components{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return components{
+		Server:            c.Server,
+		Log:               c.Log,
+		PayloadEncryption: c.PayloadEncryption,
+		DigiCert:          c.DigiCert,
+		RestClient:        c.DigiCert.RestClientConfig,
+		Cache:             c.Cache,
+		Import:            c.Import,
+		Readiness:         c.Readiness,
+		Tracing:           c.Tracing,
+	}, nil
+}
+
+// configureRestClient has every DigiCert request sent with the configured timeout, retries and allowed URLs
+func configureRestClient(config service.RestClientConfig) {
+	service.NewRestClient = service.RestClientFactory(config)
+}
+
+// newCertificateService creates the certificate service, remembering submitted requests in the idempotency
+// store file when set, or in memory otherwise, and verifying downloaded chains against the trust bundle file
+// when set, or the system roots otherwise. Missing issuers are only downloaded from the issuer URLs
+func newCertificateService(digicert DigiCertConfig, cache CacheConfig, imports ImportConfig) (*service.Certificate, error) {
+	var fetcher service.IssuerFetcher
+	if len(digicert.IssuerURLs) > 0 {
+		fetcher = service.NewHTTPIssuerFetcher(digicert.IssuerFetchTimeout, digicert.IssuerURLs)
+	}
+	opts := []service.CertificateOption{
+		service.WithDcvCheckInterval(cache.DcvCheckInterval),
+		service.WithImportConcurrency(imports.Concurrency),
+		service.WithIdempotencyStore(service.NewMemoryIdempotencyStore(cache.IdempotencyWindow)),
+		service.WithChainVerifier(service.NewChainVerifier(nil, fetcher)),
+	}
+	if cache.IdempotencyStoreFile != "" {
+		store, err := service.NewFileIdempotencyStore(cache.IdempotencyStoreFile, cache.IdempotencyWindow)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithIdempotencyStore(store))
+	}
+	if digicert.TrustBundleFile != "" {
+		roots, err := service.LoadTrustBundle(digicert.TrustBundleFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, service.WithChainVerifier(service.NewChainVerifier(roots, fetcher)))
+	}
+	return service.NewCertificateService(opts...), nil
+}
+
+func configureLogger(config LogConfig) (*zap.Logger, error) {
+	level, err := zapcore.ParseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
 	loggerConfig := zap.NewProductionConfig()
-	loggerConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	loggerConfig.Level = zap.NewAtomicLevelAt(level)
+	loggerConfig.Encoding = config.Format
 	loggerConfig.EncoderConfig = zap.NewProductionEncoderConfig()
 	loggerConfig.EncoderConfig.TimeKey = "time"
 	loggerConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
 	loggerConfig.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
 	logger, err := loggerConfig.Build()
-	if err != nil {
-		return nil, err
-	}
-
 	if err != nil {
 		return nil, err
 	}
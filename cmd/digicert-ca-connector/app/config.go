@@ -0,0 +1,313 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"github.com/venafi/digicert-ca-connector/internal/app/service"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
+	"github.com/venafi/digicert-ca-connector/internal/handler/web"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// Log formats
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+)
+
+// Config is the runtime configuration of the connector. It is read from the defaults, then the optional YAML
+// file, then the environment variables named by the env tags, each overriding the previous ones
+type Config struct {
+	Server            web.ServerConfig            `yaml:"server"`
+	Log               LogConfig                   `yaml:"log"`
+	PayloadEncryption web.PayloadEncryptionConfig `yaml:"payloadEncryption"`
+	DigiCert          DigiCertConfig              `yaml:"digicert"`
+	Cache             CacheConfig                 `yaml:"cache"`
+	Import            ImportConfig                `yaml:"import"`
+	Readiness         web.ProbeConfig             `yaml:"readiness"`
+	Tracing           tracing.Config              `yaml:"tracing"`
+}
+
+// LogConfig controls the logs
+type LogConfig struct {
+	// Level defaults to info. At debug the webhook payloads are also logged, redacted by key name only
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// DigiCertConfig controls the requests sent to DigiCert, and the verification of the chains it returns
+type DigiCertConfig struct {
+	service.RestClientConfig `yaml:",inline"`
+	// IssuerURLs are the base URLs missing issuer certificates may be downloaded from, when a chain lacks its
+	// intermediates. Missing issuers are never downloaded when empty
+	IssuerURLs []string `yaml:"issuerUrls" env:"CHAIN_ISSUER_URLS"`
+	// IssuerFetchTimeout bounds the download of a missing issuer certificate
+	IssuerFetchTimeout time.Duration `yaml:"issuerFetchTimeout" env:"CHAIN_ISSUER_FETCH_TIMEOUT"`
+	// TrustBundleFile holds the roots the chains are verified against, instead of the system roots
+	TrustBundleFile string `yaml:"trustBundleFile" env:"CHAIN_TRUST_BUNDLE_FILE"`
+}
+
+// CacheConfig controls how long the connector remembers what it asked DigiCert
+type CacheConfig struct {
+	// DcvCheckInterval is the minimum time between two DCV checks triggered for the same order
+	DcvCheckInterval time.Duration `yaml:"dcvCheckInterval" env:"DCV_CHECK_INTERVAL"`
+	// IdempotencyWindow is how long a submitted certificate request is remembered
+	IdempotencyWindow time.Duration `yaml:"idempotencyWindow" env:"IDEMPOTENCY_WINDOW"`
+	// IdempotencyStoreFile keeps the submitted certificate requests across restarts, they are kept in memory
+	// when not set
+	IdempotencyStoreFile string `yaml:"idempotencyStoreFile" env:"IDEMPOTENCY_STORE_FILE"`
+}
+
+// ImportConfig controls the certificate imports
+type ImportConfig struct {
+	// Concurrency is how many certificates of an import batch are downloaded at the same time
+	Concurrency int `yaml:"concurrency" env:"IMPORT_CONCURRENCY"`
+}
+
+// DefaultConfig returns the configuration used when neither the file nor the environment set a value
+func DefaultConfig() *Config {
+	return &Config{
+		Server: web.ServerConfig{
+			Address:       web.DefaultAddress,
+			SystemAddress: web.DefaultSystemAddress,
+		},
+		Log: LogConfig{
+			Level:  zapcore.InfoLevel.String(),
+			Format: LogFormatJSON,
+		},
+		PayloadEncryption: web.PayloadEncryptionConfig{
+			KeyPath:        web.DefaultPayloadEncryptionKeyPath,
+			ReloadInterval: web.DefaultPayloadKeyReloadInterval,
+			GracePeriod:    web.DefaultPayloadKeyGracePeriod,
+		},
+		DigiCert: DigiCertConfig{
+			RestClientConfig: service.RestClientConfig{
+				Timeout: service.DefaultRequestTimeout,
+				Retry: service.RetryPolicy{
+					MaxRetries:  service.DefaultMaxRetries,
+					WaitTime:    service.DefaultRetryWaitTime,
+					MaxWaitTime: service.DefaultRetryMaxWaitTime,
+				},
+			},
+			IssuerFetchTimeout: service.DefaultIssuerFetchTimeout,
+		},
+		Cache: CacheConfig{
+			DcvCheckInterval:  service.DefaultDcvCheckInterval,
+			IdempotencyWindow: service.DefaultIdempotencyWindow,
+		},
+		Import: ImportConfig{
+			Concurrency: service.DefaultImportConcurrency,
+		},
+		Readiness: web.ProbeConfig{
+			CheckTimeout:  web.DefaultDigiCertCheckTimeout,
+			CheckInterval: web.DefaultDigiCertCheckInterval,
+		},
+		Tracing: tracing.Config{
+			Exporter: tracing.ExporterNone,
+		},
+	}
+}
+
+// LoadConfig reads the configuration from the YAML file at path, when not empty, and from the environment
+// lookupEnv gives access to. The configuration is not validated
+func LoadConfig(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := DefaultConfig()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("configuration file not readable: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(config); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("configuration file %s not valid: %w", path, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(config).Elem(), lookupEnv); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnv sets the fields having an env tag from the environment variable it names, when set
+func applyEnv(v reflect.Value, lookupEnv func(string) (string, bool)) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(v.Field(i), lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := lookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := setFromString(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", name, value, err)
+		}
+	}
+	return nil
+}
+
+// setFromString parses the value of an environment variable, lists and maps being comma separated
+func setFromString(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case []string:
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	case map[string]string:
+		values := map[string]string{}
+		for _, item := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a key=value pair", item)
+			}
+			values[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate checks the configuration, reporting every invalid value at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	for name, address := range map[string]string{"server.address": c.Server.Address, "server.systemAddress": c.Server.SystemAddress} {
+		_, _, err := net.SplitHostPort(address)
+		check(err == nil, "%s %q is not a host:port address", name, address)
+	}
+	check(c.Server.Address != c.Server.SystemAddress, "server.address and server.systemAddress must differ")
+	check(!c.Server.TLS.Enabled() || (c.Server.TLS.CertFile != "" && c.Server.TLS.KeyFile != ""),
+		"server.tls.certFile and server.tls.keyFile must be set together")
+
+	_, err := zapcore.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level %q is not a log level", c.Log.Level)
+	check(c.Log.Format == LogFormatJSON || c.Log.Format == LogFormatConsole, "log.format %q is not %s or %s", c.Log.Format, LogFormatJSON, LogFormatConsole)
+
+	check(c.PayloadEncryption.KeyPath != "" || c.PayloadEncryption.AllowPlaintext, "payloadEncryption.keyPath is required unless plaintext payloads are allowed")
+	check(c.PayloadEncryption.ReloadInterval >= 0, "payloadEncryption.reloadInterval must not be negative")
+	check(c.PayloadEncryption.GracePeriod >= 0, "payloadEncryption.gracePeriod must not be negative")
+	check(!c.PayloadEncryption.EncryptResponses || c.PayloadEncryption.ResponseKeyPath != "",
+		"payloadEncryption.responseKeyPath is required to encrypt the responses")
+
+	check(c.DigiCert.Timeout > 0, "digicert.timeout must be positive")
+	check(c.DigiCert.Retry.MaxRetries >= 0, "digicert.retry.maxRetries must not be negative")
+	check(c.DigiCert.Retry.WaitTime >= 0, "digicert.retry.waitTime must not be negative")
+	check(c.DigiCert.Retry.MaxWaitTime >= c.DigiCert.Retry.WaitTime, "digicert.retry.maxWaitTime must not be less than digicert.retry.waitTime")
+	for _, allowed := range c.DigiCert.AllowedURLs {
+		check(isHTTPURL(allowed), "digicert.allowedUrls entry %q is not an absolute HTTP URL", allowed)
+	}
+	for _, issuer := range c.DigiCert.IssuerURLs {
+		check(isHTTPURL(issuer), "digicert.issuerUrls entry %q is not an absolute HTTP URL", issuer)
+	}
+	check(c.DigiCert.IssuerFetchTimeout > 0, "digicert.issuerFetchTimeout must be positive")
+
+	check(c.Cache.DcvCheckInterval >= 0, "cache.dcvCheckInterval must not be negative")
+	check(c.Cache.IdempotencyWindow > 0, "cache.idempotencyWindow must be positive")
+	check(c.Import.Concurrency > 0, "import.concurrency must be positive")
+
+	check(c.Readiness.DigiCertURL == "" || isHTTPURL(c.Readiness.DigiCertURL), "readiness.digicertUrl %q is not an absolute HTTP URL", c.Readiness.DigiCertURL)
+	check(c.Readiness.CheckTimeout > 0, "readiness.checkTimeout must be positive")
+	check(c.Readiness.CheckInterval > 0, "readiness.checkInterval must be positive")
+	check(c.Readiness.DrainDelay >= 0, "readiness.drainDelay must not be negative")
+
+	check(c.Tracing.Exporter == tracing.ExporterNone || c.Tracing.Exporter == tracing.ExporterOTLP,
+		"tracing.exporter %q is not %s or %s", c.Tracing.Exporter, tracing.ExporterNone, tracing.ExporterOTLP)
+
+	return errors.Join(errs...)
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Print writes the configuration as YAML, with the values of the fields tagged as secret redacted
+func (c *Config) Print(w io.Writer) error {
+	redacted := *c
+	redactSecrets(reflect.ValueOf(&redacted).Elem())
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&redacted); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// redactSecrets replaces the values of the fields tagged as secret, the maps being replaced rather than
+// modified as they are shared with the original configuration
+func redactSecrets(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			redactSecrets(v.Field(i))
+			continue
+		}
+		if field.Tag.Get("secret") != "true" {
+			continue
+		}
+		switch value := v.Field(i).Interface().(type) {
+		case string:
+			if value != "" {
+				v.Field(i).SetString(logging.Redacted)
+			}
+		case map[string]string:
+			redacted := make(map[string]string, len(value))
+			for key := range value {
+				redacted[key] = logging.Redacted
+			}
+			v.Field(i).Set(reflect.ValueOf(redacted))
+		}
+	}
+}
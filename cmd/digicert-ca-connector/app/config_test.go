@@ -0,0 +1,156 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
+	"github.com/venafi/digicert-ca-connector/internal/handler/web"
+)
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func writeConfig(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		config, err := LoadConfig("", lookupEnv(nil))
+		require.NoError(t, err)
+		require.Equal(t, DefaultConfig(), config)
+		require.Equal(t, "info", config.Log.Level)
+		require.NoError(t, config.Validate())
+	})
+
+	t.Run("file and environment", func(t *testing.T) {
+		path := writeConfig(t, `
+server:
+  address: ":9443"
+  tls:
+    certFile: /tls/tls.crt
+    keyFile: /tls/tls.key
+log:
+  level: info
+digicert:
+  timeout: 20s
+  retry:
+    maxRetries: 5
+  allowedUrls:
+    - https://www.digicert.com/services/v2
+import:
+  concurrency: 8
+`)
+		config, err := LoadConfig(path, lookupEnv(map[string]string{
+			"LOG_LEVEL":                   "warn",
+			"PAYLOAD_ENCRYPTION_KEY_PATH": "/keys",
+			"ALLOW_PLAINTEXT_PAYLOADS":    "true",
+			"DIGICERT_ALLOWED_URLS":       "https://www.digicert.com/services/v2, https://www.digicert.eu/services/v2",
+			"CHAIN_ISSUER_URLS":           "http://cacerts.digicert.com",
+			"IDEMPOTENCY_WINDOW":          "1h",
+			"TRACING_OTLP_HEADERS":        "authorization=Bearer token,tenant=a",
+			"TLS_CERT_FILE":               "",
+		}))
+		require.NoError(t, err)
+		require.NoError(t, config.Validate())
+
+		require.Equal(t, web.ServerConfig{
+			Address:       ":9443",
+			SystemAddress: web.DefaultSystemAddress,
+			TLS:           web.TLSConfig{CertFile: "/tls/tls.crt", KeyFile: "/tls/tls.key"},
+		}, config.Server)
+		require.Equal(t, LogConfig{Level: "warn", Format: LogFormatJSON}, config.Log)
+		require.Equal(t, "/keys", config.PayloadEncryption.KeyPath)
+		require.True(t, config.PayloadEncryption.AllowPlaintext)
+		require.Equal(t, 20*time.Second, config.DigiCert.Timeout)
+		require.Equal(t, 5, config.DigiCert.Retry.MaxRetries)
+		require.Equal(t, DefaultConfig().DigiCert.Retry.WaitTime, config.DigiCert.Retry.WaitTime)
+		require.Equal(t, []string{"https://www.digicert.com/services/v2", "https://www.digicert.eu/services/v2"}, config.DigiCert.AllowedURLs)
+		require.Equal(t, []string{"http://cacerts.digicert.com"}, config.DigiCert.IssuerURLs)
+		require.Equal(t, time.Hour, config.Cache.IdempotencyWindow)
+		require.Equal(t, 8, config.Import.Concurrency)
+		require.Equal(t, map[string]string{"authorization": "Bearer token", "tenant": "a"}, config.Tracing.Headers)
+	})
+
+	t.Run("unknown file key", func(t *testing.T) {
+		_, err := LoadConfig(writeConfig(t, "server:\n  adress: \":9443\"\n"), lookupEnv(nil))
+		require.ErrorContains(t, err, "field adress not found")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), lookupEnv(nil))
+		require.ErrorContains(t, err, "configuration file not readable")
+	})
+
+	t.Run("invalid environment value", func(t *testing.T) {
+		_, err := LoadConfig("", lookupEnv(map[string]string{"PAYLOAD_ENCRYPTION_KEY_GRACE_PERIOD": "soon"}))
+		require.ErrorContains(t, err, `invalid PAYLOAD_ENCRYPTION_KEY_GRACE_PERIOD value "soon"`)
+	})
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(*Config)
+		expectedError string
+	}{
+		{name: "address", modify: func(c *Config) { c.Server.Address = "8080" }, expectedError: `server.address "8080" is not a host:port address`},
+		{name: "sameAddresses", modify: func(c *Config) { c.Server.SystemAddress = c.Server.Address }, expectedError: "server.address and server.systemAddress must differ"},
+		{name: "tlsKeyMissing", modify: func(c *Config) { c.Server.TLS.CertFile = "/tls/tls.crt" }, expectedError: "server.tls.certFile and server.tls.keyFile must be set together"},
+		{name: "logLevel", modify: func(c *Config) { c.Log.Level = "loud" }, expectedError: `log.level "loud" is not a log level`},
+		{name: "logFormat", modify: func(c *Config) { c.Log.Format = "xml" }, expectedError: `log.format "xml" is not json or console`},
+		{name: "keyPath", modify: func(c *Config) { c.PayloadEncryption.KeyPath = "" }, expectedError: "payloadEncryption.keyPath is required unless plaintext payloads are allowed"},
+		{name: "responseKey", modify: func(c *Config) { c.PayloadEncryption.EncryptResponses = true }, expectedError: "payloadEncryption.responseKeyPath is required to encrypt the responses"},
+		{name: "timeout", modify: func(c *Config) { c.DigiCert.Timeout = 0 }, expectedError: "digicert.timeout must be positive"},
+		{name: "retryWait", modify: func(c *Config) { c.DigiCert.Retry.MaxWaitTime = time.Millisecond }, expectedError: "digicert.retry.maxWaitTime must not be less than digicert.retry.waitTime"},
+		{name: "allowedURL", modify: func(c *Config) { c.DigiCert.AllowedURLs = []string{"www.digicert.com"} }, expectedError: `digicert.allowedUrls entry "www.digicert.com" is not an absolute HTTP URL`},
+		{name: "issuerURL", modify: func(c *Config) { c.DigiCert.IssuerURLs = []string{"ldap://ldap.digicert.com"} }, expectedError: `digicert.issuerUrls entry "ldap://ldap.digicert.com" is not an absolute HTTP URL`},
+		{name: "idempotencyWindow", modify: func(c *Config) { c.Cache.IdempotencyWindow = 0 }, expectedError: "cache.idempotencyWindow must be positive"},
+		{name: "importConcurrency", modify: func(c *Config) { c.Import.Concurrency = 0 }, expectedError: "import.concurrency must be positive"},
+		{name: "readinessURL", modify: func(c *Config) { c.Readiness.DigiCertURL = "digicert" }, expectedError: `readiness.digicertUrl "digicert" is not an absolute HTTP URL`},
+		{name: "readinessInterval", modify: func(c *Config) { c.Readiness.CheckInterval = 0 }, expectedError: "readiness.checkInterval must be positive"},
+		{name: "tracingExporter", modify: func(c *Config) { c.Tracing.Exporter = "jaeger" }, expectedError: `tracing.exporter "jaeger" is not none or otlp`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(config)
+			require.EqualError(t, config.Validate(), tt.expectedError)
+		})
+	}
+
+	t.Run("every error reported", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Log.Format = "xml"
+		config.Import.Concurrency = 0
+		require.Len(t, strings.Split(config.Validate().Error(), "\n"), 2)
+	})
+}
+
+func TestConfigPrint(t *testing.T) {
+	config := DefaultConfig()
+	config.Tracing = tracing.Config{Exporter: tracing.ExporterOTLP, Headers: map[string]string{"authorization": "Bearer token"}}
+
+	var out strings.Builder
+	require.NoError(t, config.Print(&out))
+	require.Contains(t, out.String(), "authorization: '[REDACTED]'")
+	require.Contains(t, out.String(), "reloadInterval: 30s")
+	require.NotContains(t, out.String(), "Bearer token")
+	require.Equal(t, "Bearer token", config.Tracing.Headers["authorization"], "the configuration itself is left untouched")
+
+	printed, err := LoadConfig(writeConfig(t, out.String()), lookupEnv(nil))
+	require.NoError(t, err)
+	require.Equal(t, config.PayloadEncryption, printed.PayloadEncryption)
+}
@@ -2,9 +2,35 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/venafi/digicert-ca-connector/cmd/digicert-ca-connector/app"
 )
 
 func main() {
-	app.New().Run()
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file`, the environment variables override its values")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration, secrets redacted, and exit")
+	flag.Parse()
+
+	config, err := app.LoadConfig(*configFile, os.LookupEnv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *printConfig {
+		if err = config.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err = config.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	app.New(config).Run()
 }
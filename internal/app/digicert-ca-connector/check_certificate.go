@@ -1,7 +1,6 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
@@ -20,13 +19,14 @@ type CheckCertificateRequest struct {
 func (svc *WebhookService) HandleCheckCertificate(c echo.Context) error {
 	req := CheckCertificateRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
 
-	cert, err := svc.Certificate.CheckCertificate(req.Connection, req.ID)
+	cert, err := svc.Certificate.CheckCertificate(ctx, req.Connection, req.ID)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
 	}
 
 	return c.JSON(http.StatusOK, cert)
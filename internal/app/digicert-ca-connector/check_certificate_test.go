@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -54,8 +55,7 @@ func TestHandleCheckCertificate(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleCheckCertificate(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -74,7 +74,7 @@ func testCheckCertificate(t *testing.T, whService *WebhookService, mockCertifica
 
 	connection := buildConnection()
 	expectedCertDetails := &domain.CertificateDetails{}
-	mockCertificateService.EXPECT().CheckCertificate(connection, certificateId).DoAndReturn(func(connection domain.Connection, id string) (*domain.CertificateDetails, error) {
+	mockCertificateService.EXPECT().CheckCertificate(gomock.Any(), connection, certificateId).DoAndReturn(func(_ context.Context, connection domain.Connection, id string) (*domain.CertificateDetails, error) {
 		if success {
 			expectedCertDetails.ID = "CertID"
 			expectedCertDetails.Status = domain.CertificateStatusIssued
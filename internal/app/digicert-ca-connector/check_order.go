@@ -1,10 +1,10 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -20,13 +20,18 @@ type CheckOrderRequest struct {
 func (svc *WebhookService) HandleCheckOrder(c echo.Context) error {
 	req := CheckCertificateRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
+	tracing.SetAttributes(ctx, tracing.OrderID.String(req.ID))
 
-	order, err := svc.Certificate.CheckOrder(req.Connection, req.ID)
+	order, err := svc.Certificate.CheckOrder(ctx, req.Connection, req.ID)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
+	}
+	if order != nil {
+		tracing.SetAttributes(ctx, tracing.OrderStatus.String(string(order.Status)))
 	}
 
 	return c.JSON(http.StatusOK, order)
@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -54,8 +55,7 @@ func TestHandleCheckOrder(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleCheckOrder(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -74,7 +74,7 @@ func testCheckOrder(t *testing.T, whService *WebhookService, mockCertificateServ
 
 	connection := buildConnection()
 	expectedDetails := &domain.OrderDetails{}
-	mockCertificateService.EXPECT().CheckOrder(connection, orderID).DoAndReturn(func(connection domain.Connection, id string) (*domain.OrderDetails, error) {
+	mockCertificateService.EXPECT().CheckOrder(gomock.Any(), connection, orderID).DoAndReturn(func(_ context.Context, connection domain.Connection, id string) (*domain.OrderDetails, error) {
 		if success {
 			expectedDetails.ID = orderID
 			expectedDetails.Status = domain.OrderStatusCompleted
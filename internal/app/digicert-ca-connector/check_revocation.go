@@ -0,0 +1,44 @@
+package digicert_ca_connector
+
+import (
+	"net/http"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CheckRevocationRequest contains request details for checking a certificate revocation request on Certificate Authority
+type CheckRevocationRequest struct {
+	Connection domain.Connection `json:"connection"`
+	RequestID  string            `json:"requestId"`
+}
+
+// CheckRevocationResponse contains the outcome of the certificate revocation request
+type CheckRevocationResponse struct {
+	RevocationStatus domain.RevocationStatus `json:"revocationStatus"`
+	ErrorMessage     *string                 `json:"errorMessage"`
+	RequestID        string                  `json:"requestId"`
+}
+
+// HandleCheckRevocation will check the outcome of a certificate revocation request on Certificate Authority
+func (svc *WebhookService) HandleCheckRevocation(c echo.Context) error {
+	req := CheckRevocationRequest{}
+	if err := c.Bind(&req); err != nil {
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
+	}
+	ctx := c.Request().Context()
+
+	resp, err := svc.Certificate.CheckRevocation(ctx, req.Connection, req.RequestID)
+	if err != nil {
+		return operationError(err.Error(), err)
+	}
+
+	return c.JSON(http.StatusOK, &CheckRevocationResponse{
+		RevocationStatus: resp.Status,
+		ErrorMessage:     resp.ErrorMessage,
+		RequestID:        resp.RequestID,
+	})
+}
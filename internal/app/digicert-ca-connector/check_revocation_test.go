@@ -0,0 +1,95 @@
+package digicert_ca_connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/digicert-ca-connector/mocks"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	checkRevocationPath = "/v1/checkrevocation"
+	revocationRequestID = "42"
+)
+
+// TestHandleCheckRevocation ...
+func TestHandleCheckRevocation(t *testing.T) {
+	e := echo.New()
+	require.NotNil(t, e)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCertificateService := mocks.NewMockCertificateService(ctrl)
+	require.NotNil(t, mockCertificateService)
+
+	whService := NewWebhookService(nil, nil, mockCertificateService)
+	require.NotNil(t, whService)
+
+	t.Parallel()
+
+	t.Run("revoked", func(t *testing.T) {
+		testCheckRevocation(t, whService, mockCertificateService, e, domain.RevocationStatusRevoked, nil)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		errMessage := "revocation request 42 has been rejected"
+		testCheckRevocation(t, whService, mockCertificateService, e, domain.RevocationStatusRejected, &errMessage)
+	})
+
+	t.Run("invalid request malformed body", func(t *testing.T) {
+		_, ctx := setupPost(e, checkRevocationPath, "{")
+
+		err := whService.HandleCheckRevocation(ctx)
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
+	})
+}
+
+func testCheckRevocation(t *testing.T, whService *WebhookService, mockCertificateService *mocks.MockCertificateService, e *echo.Echo, status domain.RevocationStatus, errMessage *string) {
+	recorder, ctx := setupPost(e, checkRevocationPath, fmt.Sprintf(`{
+			"connection": {
+				"configuration": {
+				    "serverUrl": "%s"
+		       },
+		       "credentials": {
+		           "apiKey": "%s"
+		       }
+		   },
+          "requestId": "%s"
+		}`, serverURL, apiKey, revocationRequestID))
+
+	connection := buildConnection()
+	mockCertificateService.EXPECT().CheckRevocation(gomock.Any(), connection, revocationRequestID).Return(&domain.RevocationDetails{
+		Status:       status,
+		ErrorMessage: errMessage,
+		RequestID:    revocationRequestID,
+	}, nil)
+
+	err := whService.HandleCheckRevocation(ctx)
+	require.NoError(t, err)
+
+	response := recorder.Result()
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(response.Body)
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	cr := &CheckRevocationResponse{}
+	data, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	err = json.Unmarshal(data, cr)
+	require.NoError(t, err)
+
+	require.Equal(t, status, cr.RevocationStatus)
+	require.Equal(t, errMessage, cr.ErrorMessage)
+	require.Equal(t, revocationRequestID, cr.RequestID)
+}
@@ -1,27 +1,34 @@
 package digicert_ca_connector
 
 import (
+	"context"
+	"github.com/labstack/echo/v4"
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.uber.org/zap"
 )
 
 // ConnectionService ...
 type ConnectionService interface {
-	TestConnection(connection domain.Connection) error
+	TestConnection(ctx context.Context, connection domain.Connection) error
 }
 
 // OptionsService ...
 type OptionsService interface {
-	GetOptions(connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error)
-	ValidateProduct(connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error)
+	GetOptions(ctx context.Context, connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error)
+	ValidateProduct(ctx context.Context, connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error)
 }
 
 // CertificateService ...
 type CertificateService interface {
-	RequestCertificate(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error)
-	CheckOrder(connection domain.Connection, id string) (*domain.OrderDetails, error)
-	CheckCertificate(connection domain.Connection, id string) (*domain.CertificateDetails, error)
-	RetrieveCertificates(connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error)
-	RevokeCertificate(connection domain.Connection, serialNumber string, reason int) (*domain.RevocationDetails, error)
+	RequestCertificate(ctx context.Context, connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error)
+	CheckOrder(ctx context.Context, connection domain.Connection, id string) (*domain.OrderDetails, error)
+	CheckCertificate(ctx context.Context, connection domain.Connection, id string) (*domain.CertificateDetails, error)
+	RetrieveCertificates(ctx context.Context, connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error)
+	RevokeCertificate(ctx context.Context, connection domain.Connection, serialNumber string, reason int) (*domain.RevocationDetails, error)
+	RevokeOrder(ctx context.Context, connection domain.Connection, orderID string, reason int) (*domain.RevocationDetails, error)
+	ProcessApproval(ctx context.Context, connection domain.Connection, requestID string, approve bool, comment string) (*domain.ApprovalDetails, error)
+	CheckRevocation(ctx context.Context, connection domain.Connection, requestID string) (*domain.RevocationDetails, error)
 }
 
 // WebhookService ...
@@ -39,3 +46,8 @@ func NewWebhookService(connections ConnectionService, options OptionsService, ce
 		Certificate: certificate,
 	}
 }
+
+// logger returns the logger of the request, which tags every line with the request ID
+func logger(c echo.Context) *zap.Logger {
+	return logging.FromContext(c.Request().Context())
+}
@@ -0,0 +1,47 @@
+package digicert_ca_connector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+// unmarshalError fails an operation whose request body cannot be bound
+func unmarshalError(err error) error {
+	return &domain.OperationError{
+		Status:  http.StatusBadRequest,
+		Code:    domain.ErrorCodeInvalidRequest,
+		Message: fmt.Sprintf("failed to unmarshal json: %s", err.Error()),
+		Err:     err,
+	}
+}
+
+// invalidRequestError fails an operation whose request is missing details
+func invalidRequestError(message string) error {
+	return &domain.OperationError{
+		Status:  http.StatusBadRequest,
+		Code:    domain.ErrorCodeInvalidRequest,
+		Message: message,
+	}
+}
+
+// operationError fails an operation on an error of the Certificate Authority, which is worth retrying
+// when the server could not be reached or was unavailable
+func operationError(message string, err error) error {
+	opErr := &domain.OperationError{
+		Status:  http.StatusBadRequest,
+		Code:    domain.ErrorCodeOperationFailed,
+		Message: message,
+		Err:     err,
+	}
+	var retryable interface{ Retryable() bool }
+	var netErr net.Error
+	if (errors.As(err, &retryable) && retryable.Retryable()) || errors.As(err, &netErr) {
+		opErr.Code = domain.ErrorCodeCAUnavailable
+		opErr.Retryable = true
+	}
+	return opErr
+}
@@ -0,0 +1,55 @@
+package digicert_ca_connector
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string   { return "DigiCert error" }
+func (e retryableError) Retryable() bool { return bool(e) }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestOperationError(t *testing.T) {
+	tests := []struct {
+		name              string
+		err               error
+		expectedCode      domain.ErrorCode
+		expectedRetryable bool
+	}{
+		{name: "failed", err: errors.New("invalid product"), expectedCode: domain.ErrorCodeOperationFailed},
+		{name: "clientError", err: retryableError(false), expectedCode: domain.ErrorCodeOperationFailed},
+		{name: "unavailable", err: retryableError(true), expectedCode: domain.ErrorCodeCAUnavailable, expectedRetryable: true},
+		{
+			name:              "unreachable",
+			err:               &url.Error{Op: "Get", URL: "https://digicert-test", Err: timeoutError{}},
+			expectedCode:      domain.ErrorCodeCAUnavailable,
+			expectedRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := operationError("failed operation", tt.err)
+
+			var opErr *domain.OperationError
+			require.ErrorAs(t, err, &opErr)
+			require.Equal(t, http.StatusBadRequest, opErr.Status)
+			require.Equal(t, tt.expectedCode, opErr.Code)
+			require.Equal(t, tt.expectedRetryable, opErr.Retryable)
+			require.Equal(t, "failed operation", opErr.Message)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
@@ -25,13 +24,14 @@ type GetOptionsResponse struct {
 func (svc *WebhookService) HandleGetOptions(c echo.Context) error {
 	req := GetOptionsRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
 
-	po, io, err := svc.Options.GetOptions(req.Connection)
+	po, io, err := svc.Options.GetOptions(ctx, req.Connection)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
 	}
 
 	return c.JSON(http.StatusOK, &GetOptionsResponse{
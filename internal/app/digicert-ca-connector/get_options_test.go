@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -58,8 +59,7 @@ func TestHandleGetOptions(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleGetOptions(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -77,7 +77,7 @@ func testGetOptions(t *testing.T, whService *WebhookService, mockOptionsServices
 
 	connection := buildConnection()
 
-	mockOptionsServices.EXPECT().GetOptions(connection).DoAndReturn(func(connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
+	mockOptionsServices.EXPECT().GetOptions(gomock.Any(), connection).DoAndReturn(func(_ context.Context, connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
 		return []domain.ProductOption{
 				{
 					Name:  "SSL Certificates",
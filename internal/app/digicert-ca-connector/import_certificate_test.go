@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -56,8 +57,7 @@ func TestHandleImportCertificates(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleImportCertificates(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -97,7 +97,7 @@ func testRetrieveCertificates(t *testing.T, whService *WebhookService, mockCerti
 		IncludeExpiredCertificates: true,
 	}
 	expectedImportDetails := &domain.ImportDetails{}
-	mockCertificateService.EXPECT().RetrieveCertificates(connection, option, importConfiguration, lastProcessedCertificateID, batchSize).DoAndReturn(func(connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, lastProcessedCertificateId string, batchSize int) (*domain.ImportDetails, error) {
+	mockCertificateService.EXPECT().RetrieveCertificates(gomock.Any(), connection, option, importConfiguration, lastProcessedCertificateID, batchSize).DoAndReturn(func(_ context.Context, connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, lastProcessedCertificateId string, batchSize int) (*domain.ImportDetails, error) {
 		if complete {
 			expectedImportDetails.ImportStatus = domain.ImportStatusCompleted
 		} else {
@@ -23,14 +23,15 @@ type ImportCertificatesRequest struct {
 func (svc *WebhookService) HandleImportCertificates(c echo.Context) error {
 	req := ImportCertificatesRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
 
-	res, err := svc.Certificate.RetrieveCertificates(req.Connection, req.Option, req.Configuration, req.LastProcessedCertificateID, req.BatchSize)
+	res, err := svc.Certificate.RetrieveCertificates(ctx, req.Connection, req.Option, req.Configuration, req.LastProcessedCertificateID, req.BatchSize)
 	if err != nil {
-		zap.L().Error("failed to retrieve certificates from Certificate Authority", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to retrieve certificates from Certificate Authority: %s", err.Error()))
+		logger(c).Error("failed to retrieve certificates from Certificate Authority", zap.Error(err))
+		return operationError(fmt.Sprintf("failed to retrieve certificates from Certificate Authority: %s", err.Error()), err)
 	}
 	return c.JSON(http.StatusOK, res)
 }
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/golang/mock/gomock"
@@ -32,9 +33,9 @@ func (m *MockCertificateService) EXPECT() *MockCertificateServiceMockRecorder {
 }
 
 // RequestCertificate mocks base method.
-func (m *MockCertificateService) RequestCertificate(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
+func (m *MockCertificateService) RequestCertificate(ctx context.Context, connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RequestCertificate", connection, pkcs10Request, product, productOptionName, validitySeconds, productDetails)
+	ret := m.ctrl.Call(m, "RequestCertificate", ctx, connection, pkcs10Request, product, productOptionName, validitySeconds, productDetails)
 	ret0, _ := ret[0].(*domain.CertificateDetails)
 	ret1, _ := ret[1].(*domain.OrderDetails)
 	ret2, _ := ret[2].(error)
@@ -42,67 +43,112 @@ func (m *MockCertificateService) RequestCertificate(connection domain.Connection
 }
 
 // RequestCertificate indicates an expected call of MockCertificateService.
-func (mr *MockCertificateServiceMockRecorder) RequestCertificate(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) *gomock.Call {
+func (mr *MockCertificateServiceMockRecorder) RequestCertificate(ctx any, connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestCertificate", reflect.TypeOf((*MockCertificateService)(nil).RequestCertificate), connection, pkcs10Request, product, productOptionName, validitySeconds, productDetails)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestCertificate", reflect.TypeOf((*MockCertificateService)(nil).RequestCertificate), ctx, connection, pkcs10Request, product, productOptionName, validitySeconds, productDetails)
 }
 
 // CheckOrder mocks base method.
-func (m *MockCertificateService) CheckOrder(connection domain.Connection, id string) (*domain.OrderDetails, error) {
+func (m *MockCertificateService) CheckOrder(ctx context.Context, connection domain.Connection, id string) (*domain.OrderDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckOrder", connection, id)
+	ret := m.ctrl.Call(m, "CheckOrder", ctx, connection, id)
 	ret0, _ := ret[0].(*domain.OrderDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CheckOrder indicates an expected call of MockCertificateService.
-func (mr *MockCertificateServiceMockRecorder) CheckOrder(connection domain.Connection, id string) *gomock.Call {
+func (mr *MockCertificateServiceMockRecorder) CheckOrder(ctx any, connection domain.Connection, id string) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOrder", reflect.TypeOf((*MockCertificateService)(nil).CheckOrder), connection, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOrder", reflect.TypeOf((*MockCertificateService)(nil).CheckOrder), ctx, connection, id)
 }
 
 // CheckCertificate mocks base method.
-func (m *MockCertificateService) CheckCertificate(connection domain.Connection, id string) (*domain.CertificateDetails, error) {
+func (m *MockCertificateService) CheckCertificate(ctx context.Context, connection domain.Connection, id string) (*domain.CertificateDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckCertificate", connection, id)
+	ret := m.ctrl.Call(m, "CheckCertificate", ctx, connection, id)
 	ret0, _ := ret[0].(*domain.CertificateDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CheckCertificate indicates an expected call of MockCertificateService.
-func (mr *MockCertificateServiceMockRecorder) CheckCertificate(connection domain.Connection, id string) *gomock.Call {
+func (mr *MockCertificateServiceMockRecorder) CheckCertificate(ctx any, connection domain.Connection, id string) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckCertificate", reflect.TypeOf((*MockCertificateService)(nil).CheckCertificate), connection, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckCertificate", reflect.TypeOf((*MockCertificateService)(nil).CheckCertificate), ctx, connection, id)
 }
 
 // RetrieveCertificates mocks base method.
-func (m *MockCertificateService) RetrieveCertificates(connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error) {
+func (m *MockCertificateService) RetrieveCertificates(ctx context.Context, connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RetrieveCertificates", connection, option, configuration, startCursor, batchSize)
+	ret := m.ctrl.Call(m, "RetrieveCertificates", ctx, connection, option, configuration, startCursor, batchSize)
 	ret0, _ := ret[0].(*domain.ImportDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RetrieveCertificates indicates an expected call of MockCertificateService.
-func (mr *MockCertificateServiceMockRecorder) RetrieveCertificates(connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) *gomock.Call {
+func (mr *MockCertificateServiceMockRecorder) RetrieveCertificates(ctx any, connection domain.Connection, option domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveCertificates", reflect.TypeOf((*MockCertificateService)(nil).RetrieveCertificates), connection, option, configuration, startCursor, batchSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveCertificates", reflect.TypeOf((*MockCertificateService)(nil).RetrieveCertificates), ctx, connection, option, configuration, startCursor, batchSize)
 }
 
 // RevokeCertificate mocks base method.
-func (m *MockCertificateService) RevokeCertificate(connection domain.Connection, serialNumber string, reasonCode int) (*domain.RevocationDetails, error) {
+func (m *MockCertificateService) RevokeCertificate(ctx context.Context, connection domain.Connection, serialNumber string, reasonCode int) (*domain.RevocationDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RevokeCertificate", connection, serialNumber, reasonCode)
+	ret := m.ctrl.Call(m, "RevokeCertificate", ctx, connection, serialNumber, reasonCode)
 	ret0, _ := ret[0].(*domain.RevocationDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RevokeCertificate indicates an expected call of MockCertificateService.
-func (mr *MockCertificateServiceMockRecorder) RevokeCertificate(connection domain.Connection, serialNumber string, reasonCode int) *gomock.Call {
+func (mr *MockCertificateServiceMockRecorder) RevokeCertificate(ctx any, connection domain.Connection, serialNumber string, reasonCode int) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeCertificate", reflect.TypeOf((*MockCertificateService)(nil).RevokeCertificate), connection, serialNumber, reasonCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeCertificate", reflect.TypeOf((*MockCertificateService)(nil).RevokeCertificate), ctx, connection, serialNumber, reasonCode)
+}
+
+// ProcessApproval mocks base method.
+func (m *MockCertificateService) ProcessApproval(ctx context.Context, connection domain.Connection, requestID string, approve bool, comment string) (*domain.ApprovalDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessApproval", ctx, connection, requestID, approve, comment)
+	ret0, _ := ret[0].(*domain.ApprovalDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessApproval indicates an expected call of MockCertificateService.
+func (mr *MockCertificateServiceMockRecorder) ProcessApproval(ctx any, connection domain.Connection, requestID string, approve bool, comment string) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessApproval", reflect.TypeOf((*MockCertificateService)(nil).ProcessApproval), ctx, connection, requestID, approve, comment)
+}
+
+// CheckRevocation mocks base method.
+func (m *MockCertificateService) CheckRevocation(ctx context.Context, connection domain.Connection, requestID string) (*domain.RevocationDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckRevocation", ctx, connection, requestID)
+	ret0, _ := ret[0].(*domain.RevocationDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckRevocation indicates an expected call of MockCertificateService.
+func (mr *MockCertificateServiceMockRecorder) CheckRevocation(ctx any, connection domain.Connection, requestID string) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckRevocation", reflect.TypeOf((*MockCertificateService)(nil).CheckRevocation), ctx, connection, requestID)
+}
+
+// RevokeOrder mocks base method.
+func (m *MockCertificateService) RevokeOrder(ctx context.Context, connection domain.Connection, orderID string, reason int) (*domain.RevocationDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOrder", ctx, connection, orderID, reason)
+	ret0, _ := ret[0].(*domain.RevocationDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOrder indicates an expected call of MockCertificateService.
+func (mr *MockCertificateServiceMockRecorder) RevokeOrder(ctx any, connection domain.Connection, orderID string, reason int) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOrder", reflect.TypeOf((*MockCertificateService)(nil).RevokeOrder), ctx, connection, orderID, reason)
 }
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/golang/mock/gomock"
@@ -32,15 +33,15 @@ func (m *MockConnectorServices) EXPECT() *MockConnectorServicesMockRecorder {
 }
 
 // TestConnection mocks base method.
-func (m *MockConnectorServices) TestConnection(connection domain.Connection) error {
+func (m *MockConnectorServices) TestConnection(ctx context.Context, connection domain.Connection) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TestConnection", connection)
+	ret := m.ctrl.Call(m, "TestConnection", ctx, connection)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TestConnection indicates an expected call of TestConnection.
-func (mr *MockConnectorServicesMockRecorder) TestConnection(connection domain.Connection) *gomock.Call {
+func (mr *MockConnectorServicesMockRecorder) TestConnection(ctx any, connection domain.Connection) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestConnection", reflect.TypeOf((*MockConnectorServices)(nil).TestConnection), connection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestConnection", reflect.TypeOf((*MockConnectorServices)(nil).TestConnection), ctx, connection)
 }
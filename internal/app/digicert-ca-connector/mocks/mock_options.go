@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/golang/mock/gomock"
@@ -32,9 +33,9 @@ func (m *MockOptionsServices) EXPECT() *MockOptionsServicesMockRecorder {
 }
 
 // GetOptions mocks base method.
-func (m *MockOptionsServices) GetOptions(connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
+func (m *MockOptionsServices) GetOptions(ctx context.Context, connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOptions", connection)
+	ret := m.ctrl.Call(m, "GetOptions", ctx, connection)
 	ret0 := ret[0].([]domain.ProductOption)
 	ret1 := ret[1].([]domain.ImportOption)
 	ret2, _ := ret[2].(error)
@@ -42,22 +43,22 @@ func (m *MockOptionsServices) GetOptions(connection domain.Connection) ([]domain
 }
 
 // GetOptions indicates an expected call of GetOptions.
-func (mr *MockOptionsServicesMockRecorder) GetOptions(connection domain.Connection) *gomock.Call {
+func (mr *MockOptionsServicesMockRecorder) GetOptions(ctx any, connection domain.Connection) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOptions", reflect.TypeOf((*MockOptionsServices)(nil).GetOptions), connection)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOptions", reflect.TypeOf((*MockOptionsServices)(nil).GetOptions), ctx, connection)
 }
 
 // ValidateProduct mocks base method.
-func (m *MockOptionsServices) ValidateProduct(connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error) {
+func (m *MockOptionsServices) ValidateProduct(ctx context.Context, connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateProduct", connection, name, product)
+	ret := m.ctrl.Call(m, "ValidateProduct", ctx, connection, name, product)
 	ret0 := ret[0].([]domain.ProductError)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ValidateProduct indicates an expected call of GetOptions.
-func (mr *MockOptionsServicesMockRecorder) ValidateProduct(connection domain.Connection, name string, product domain.Product) *gomock.Call {
+func (mr *MockOptionsServicesMockRecorder) ValidateProduct(ctx any, connection domain.Connection, name string, product domain.Product) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateProduct", reflect.TypeOf((*MockOptionsServices)(nil).ValidateProduct), connection, name, product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateProduct", reflect.TypeOf((*MockOptionsServices)(nil).ValidateProduct), ctx, connection, name, product)
 }
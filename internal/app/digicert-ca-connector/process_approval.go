@@ -0,0 +1,35 @@
+package digicert_ca_connector
+
+import (
+	"net/http"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ProcessApprovalRequest contains request details for approving or rejecting a pending order request on Certificate Authority
+type ProcessApprovalRequest struct {
+	Connection domain.Connection `json:"connection"`
+	RequestID  string            `json:"requestId"`
+	Approve    bool              `json:"approve"`
+	Comment    string            `json:"comment"`
+}
+
+// HandleProcessApproval will approve or reject a pending order request on Certificate Authority
+func (svc *WebhookService) HandleProcessApproval(c echo.Context) error {
+	req := ProcessApprovalRequest{}
+	if err := c.Bind(&req); err != nil {
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
+	}
+	ctx := c.Request().Context()
+
+	approval, err := svc.Certificate.ProcessApproval(ctx, req.Connection, req.RequestID, req.Approve, req.Comment)
+	if err != nil {
+		return operationError(err.Error(), err)
+	}
+
+	return c.JSON(http.StatusOK, approval)
+}
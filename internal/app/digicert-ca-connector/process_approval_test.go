@@ -0,0 +1,108 @@
+package digicert_ca_connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/digicert-ca-connector/mocks"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	processApprovalPath = "/v1/processapproval"
+	approvalRequestID   = "requestId"
+	approvalComment     = "approved by automation"
+)
+
+// TestHandleProcessApproval ...
+func TestHandleProcessApproval(t *testing.T) {
+	e := echo.New()
+	require.NotNil(t, e)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCertificateService := mocks.NewMockCertificateService(ctrl)
+	require.NotNil(t, mockCertificateService)
+
+	whService := NewWebhookService(nil, nil, mockCertificateService)
+	require.NotNil(t, whService)
+
+	t.Parallel()
+
+	t.Run("approved", func(t *testing.T) {
+		testProcessApproval(t, whService, mockCertificateService, e, true)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		testProcessApproval(t, whService, mockCertificateService, e, false)
+	})
+
+	t.Run("invalid request malformed body", func(t *testing.T) {
+		_, ctx := setupPost(e, processApprovalPath, "{")
+
+		err := whService.HandleProcessApproval(ctx)
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
+	})
+}
+
+func testProcessApproval(t *testing.T, whService *WebhookService, mockCertificateService *mocks.MockCertificateService, e *echo.Echo, approve bool) {
+	recorder, ctx := setupPost(e, processApprovalPath, fmt.Sprintf(`{
+			"connection": {
+				"configuration": {
+				    "serverUrl": "%s"
+		       },
+		       "credentials": {
+		           "apiKey": "%s"
+		       }
+		   },
+          "requestId": "%s",
+          "approve": %t,
+          "comment": "%s"
+		}`, serverURL, apiKey, approvalRequestID, approve, approvalComment))
+
+	connection := buildConnection()
+	mockCertificateService.EXPECT().ProcessApproval(gomock.Any(), connection, approvalRequestID, approve, approvalComment).DoAndReturn(func(_ context.Context, connection domain.Connection, requestID string, approve bool, comment string) (*domain.ApprovalDetails, error) {
+		status := domain.ApprovalStatusRejected
+		if approve {
+			status = domain.ApprovalStatusApproved
+		}
+		return &domain.ApprovalDetails{
+			RequestID: requestID,
+			Status:    status,
+		}, nil
+	})
+
+	err := whService.HandleProcessApproval(ctx)
+	require.NoError(t, err)
+
+	response := recorder.Result()
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(response.Body)
+
+	require.NotNil(t, response)
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	ad := &domain.ApprovalDetails{}
+	data, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	err = json.Unmarshal(data, ad)
+	require.NoError(t, err)
+
+	require.Equal(t, approvalRequestID, ad.RequestID)
+	if approve {
+		require.Equal(t, domain.ApprovalStatusApproved, ad.Status)
+	} else {
+		require.Equal(t, domain.ApprovalStatusRejected, ad.Status)
+	}
+}
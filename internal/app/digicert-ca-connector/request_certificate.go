@@ -1,10 +1,10 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -18,6 +18,8 @@ type RequestCertificateRequest struct {
 	Product           domain.Product         `json:"product"`
 	Pkcs10Request     string                 `json:"pkcs10Request"`
 	ProductDetails    *domain.ProductDetails `json:"productDetails"`
+	Comments          string                 `json:"comments"`
+	AdditionalEmails  []string               `json:"additionalEmails"`
 }
 
 // RequestCertificateResponse contains certificate or/and order details for the submitted certificate request
@@ -30,13 +32,26 @@ type RequestCertificateResponse struct {
 func (svc *WebhookService) HandleRequestCertificate(c echo.Context) error {
 	req := RequestCertificateRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
+	if req.ProductDetails != nil {
+		tracing.SetAttributes(ctx, tracing.ProductNameID.String(req.ProductDetails.NameID))
+	}
+
+	// comments and notification emails given with the request complement the ones configured on the product
+	if req.Comments != "" {
+		req.Product.Comments = req.Comments
+	}
+	req.Product.AdditionalEmails = append(req.Product.AdditionalEmails, req.AdditionalEmails...)
 
-	cert, order, err := svc.Certificate.RequestCertificate(req.Connection, req.Pkcs10Request, req.Product, req.ProductOptionName, req.ValiditySeconds, req.ProductDetails)
+	cert, order, err := svc.Certificate.RequestCertificate(ctx, req.Connection, req.Pkcs10Request, req.Product, req.ProductOptionName, req.ValiditySeconds, req.ProductDetails)
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
+	}
+	if order != nil {
+		tracing.SetAttributes(ctx, tracing.OrderID.String(order.ID), tracing.OrderStatus.String(string(order.Status)))
 	}
 
 	return c.JSON(http.StatusOK, &RequestCertificateResponse{
@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -67,6 +68,10 @@ func TestHandleRequestCertificate(t *testing.T) {
 		testRequestCertificate(t, whService, mockCertificateService, e, false, true)
 	})
 
+	t.Run("request comments and emails", func(t *testing.T) {
+		testRequestCertificateAnnotations(t, whService, mockCertificateService, e)
+	})
+
 	t.Run("invalid request no body", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -76,8 +81,7 @@ func TestHandleRequestCertificate(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleRequestCertificate(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -117,7 +121,7 @@ func testRequestCertificate(t *testing.T, whService *WebhookService, mockCertifi
 	}
 	var expectedCertDetails domain.CertificateDetails
 	var expectedOrderDetails domain.OrderDetails
-	mockCertificateService.EXPECT().RequestCertificate(connection, pkcs10Request, po, productOptionName, validitySeconds, &pd).DoAndReturn(func(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
+	mockCertificateService.EXPECT().RequestCertificate(gomock.Any(), connection, pkcs10Request, po, productOptionName, validitySeconds, &pd).DoAndReturn(func(_ context.Context, connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
 		if success {
 			if orderDetails {
 				expectedOrderDetails.ID = "OrderID"
@@ -179,3 +183,45 @@ func testRequestCertificate(t *testing.T, whService *WebhookService, mockCertifi
 		}
 	}
 }
+
+func testRequestCertificateAnnotations(t *testing.T, whService *WebhookService, mockCertificateService *mocks.MockCertificateService, e *echo.Echo) {
+	pd := domain.ProductDetails{
+		NameID: productNameId,
+	}
+	pdJson, _ := json.Marshal(pd)
+	recorder, ctx := setupPost(e, requestCertificatePath, fmt.Sprintf(`{
+			"connection": {
+				"configuration": {
+				    "serverUrl": "%s"
+		       },
+		       "credentials": {
+		           "apiKey": "%s"
+		       }
+		   },
+           "productOptionName": "%s",
+           "product": {
+               "nameId": "%s",
+               "comments": "product comment",
+               "additionalEmails": ["team@example.com"]
+           },
+           "pkcs10Request": "%s",
+           "validitySeconds": %d,
+           "productDetails": %s,
+           "comments": "request comment",
+           "additionalEmails": ["owner@example.com"]
+		}`, serverURL, apiKey, productOptionName, productNameId, pkcs10Request, validitySeconds, pdJson))
+
+	po := domain.Product{
+		NameID:           productNameId,
+		Comments:         "request comment",
+		AdditionalEmails: []string{"team@example.com", "owner@example.com"},
+	}
+	mockCertificateService.EXPECT().RequestCertificate(gomock.Any(), buildConnection(), pkcs10Request, po, productOptionName, validitySeconds, &pd).Return(nil, &domain.OrderDetails{
+		ID:     "OrderID",
+		Status: domain.OrderStatusProcessing,
+	}, nil)
+
+	err := whService.HandleRequestCertificate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -1,9 +1,9 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 	"go.uber.org/zap"
 	"net/http"
 )
@@ -13,27 +13,54 @@ type RevokeCertificateRequest struct {
 	Connection                domain.Connection                `json:"connection"`
 	CertificateRevocationData domain.CertificateRevocationData `json:"certificateRevocationData"`
 	Reason                    int                              `json:"reason"`
+	RevokeOrder               bool                             `json:"revokeOrder"`
 }
 
 type RevokeCertificateResponse struct {
-	RevocationStatus domain.RevocationStatus `json:"revocationStatus"`
-	ErrorMessage     *string                 `json:"errorMessage"`
+	RevocationStatus        domain.RevocationStatus `json:"revocationStatus"`
+	ErrorMessage            *string                 `json:"errorMessage"`
+	RequestID               string                  `json:"requestId,omitempty"`
+	RequestIDs              []string                `json:"requestIds,omitempty"`
+	SubmittedCertificateIDs []string                `json:"submittedCertificateIds,omitempty"`
+	RevokedCertificateIDs   []string                `json:"revokedCertificateIds,omitempty"`
+	FailedCertificateIDs    []string                `json:"failedCertificateIds,omitempty"`
 }
 
 // HandleRevokeCertificate will submit certificate revocation request to Certificate Authority
 func (svc *WebhookService) HandleRevokeCertificate(c echo.Context) error {
 	req := RevokeCertificateRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
 
-	resp, err := svc.Certificate.RevokeCertificate(req.Connection, req.CertificateRevocationData.SerialNumber, req.Reason)
+	var resp *domain.RevocationDetails
+	var err error
+	data := req.CertificateRevocationData
+	// the whole order is revoked when asked to, or when the order is the only certificate reference given
+	if req.RevokeOrder || (data.SerialNumber == "" && data.CaCertificateIdentifier == "" && data.CaOrderIdentifier != "") {
+		if data.CaOrderIdentifier == "" {
+			return invalidRequestError("caOrderIdentifier is required to revoke an order")
+		}
+		tracing.SetAttributes(ctx, tracing.OrderID.String(data.CaOrderIdentifier))
+		resp, err = svc.Certificate.RevokeOrder(ctx, req.Connection, data.CaOrderIdentifier, req.Reason)
+	} else {
+		resp, err = svc.Certificate.RevokeCertificate(ctx, req.Connection, data.SerialNumber, req.Reason)
+	}
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
 	}
 
-	res := RevokeCertificateResponse{RevocationStatus: resp.Status, ErrorMessage: resp.ErrorMessage}
+	res := RevokeCertificateResponse{
+		RevocationStatus:        resp.Status,
+		ErrorMessage:            resp.ErrorMessage,
+		RequestID:               resp.RequestID,
+		RequestIDs:              resp.RequestIDs,
+		SubmittedCertificateIDs: resp.SubmittedCertificateIDs,
+		RevokedCertificateIDs:   resp.RevokedCertificateIDs,
+		FailedCertificateIDs:    resp.FailedCertificateIDs,
+	}
 
 	return c.JSON(http.StatusOK, &res)
 }
@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/golang/mock/gomock"
@@ -57,6 +58,10 @@ func TestHandleRevokeCertificate(t *testing.T) {
 		testRevokeCertificate(t, whService, mockCertificateService, e, false)
 	})
 
+	t.Run("order revocation", func(t *testing.T) {
+		testRevokeOrder(t, whService, mockCertificateService, e)
+	})
+
 	t.Run("invalid request no body", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -66,8 +71,7 @@ func TestHandleRevokeCertificate(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleRevokeCertificate(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -96,7 +100,7 @@ func testRevokeCertificate(t *testing.T, whService *WebhookService, mockCertific
 
 	connection := buildConnection()
 	var expectedRevocationDetails domain.RevocationDetails
-	mockCertificateService.EXPECT().RevokeCertificate(connection, serialNumber, reason).DoAndReturn(func(connection domain.Connection, serialNumber string, reason int) (*domain.RevocationDetails, error) {
+	mockCertificateService.EXPECT().RevokeCertificate(gomock.Any(), connection, serialNumber, reason).DoAndReturn(func(_ context.Context, connection domain.Connection, serialNumber string, reason int) (*domain.RevocationDetails, error) {
 		if success {
 			expectedRevocationDetails.Status = domain.RevocationStatusSubmitted
 		} else {
@@ -131,3 +135,47 @@ func testRevokeCertificate(t *testing.T, whService *WebhookService, mockCertific
 		require.Equal(t, *expectedRevocationDetails.ErrorMessage, *cr.ErrorMessage)
 	}
 }
+
+func testRevokeOrder(t *testing.T, whService *WebhookService, mockCertificateService *mocks.MockCertificateService, e *echo.Echo) {
+	recorder, ctx := setupPost(e, revokeCertificatePath, fmt.Sprintf(`{
+			"connection": {
+				"configuration": {
+				    "serverUrl": "%s"
+		       },
+		       "credentials": {
+		           "apiKey": "%s"
+		       }
+		   },
+           "certificateRevocationData": {
+               "caOrderIdentifier": "%s"
+           },
+           "reason": %d
+		}`, serverURL, apiKey, caOrderIdentifier, reason))
+
+	connection := buildConnection()
+	mockCertificateService.EXPECT().RevokeOrder(gomock.Any(), connection, caOrderIdentifier, reason).Return(&domain.RevocationDetails{
+		Status:                  domain.RevocationStatusSubmitted,
+		RequestIDs:              []string{"42", "43"},
+		SubmittedCertificateIDs: []string{"100", "101"},
+		RevokedCertificateIDs:   []string{"102"},
+	}, nil)
+
+	err := whService.HandleRevokeCertificate(ctx)
+	require.NoError(t, err)
+
+	response := recorder.Result()
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(response.Body)
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	cr := &RevokeCertificateResponse{}
+	err = cr.unmarshal(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, domain.RevocationStatusSubmitted, cr.RevocationStatus)
+	require.Equal(t, []string{"100", "101"}, cr.SubmittedCertificateIDs)
+	require.Equal(t, []string{"102"}, cr.RevokedCertificateIDs)
+	require.Equal(t, []string{"42", "43"}, cr.RequestIDs)
+	require.Empty(t, cr.FailedCertificateIDs)
+}
@@ -33,21 +33,22 @@ type TestConnectionResponse struct {
 func (svc *WebhookService) HandleTestConnection(c echo.Context) error {
 	req := TestConnectionRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
 
 	res := TestConnectionResponse{
 		Result: TestConnectionFailed,
 	}
 
-	err := svc.Connections.TestConnection(req.Connection)
+	err := svc.Connections.TestConnection(ctx, req.Connection)
 	if err != nil {
-		zap.L().Error("error connecting to DigiCert Certificate Authority", zap.String("error", err.Error()))
+		logger(c).Error("error connecting to DigiCert Certificate Authority", zap.String("error", err.Error()))
 		res.Message = fmt.Sprintf("failed to connect to DigiCert Certificate Authority: %s", err.Error())
 	} else {
 		res.Result = TestConnectionSuccess
-		zap.L().Info("success connecting to DigiCert Certificate Authority")
+		logger(c).Info("success connecting to DigiCert Certificate Authority")
 	}
 	return c.JSON(http.StatusOK, res)
 }
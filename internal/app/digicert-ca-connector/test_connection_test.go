@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -50,21 +51,11 @@ func buildConnection() domain.Connection {
 	}
 }
 
-func requireStatusBadRequest(t *testing.T, expectedErrorMessageInBody string, response *http.Response) {
-	var err error
-
-	require.NotNil(t, response)
-	defer func(body io.ReadCloser) {
-		err = body.Close()
-		require.NoError(t, err)
-	}(response.Body)
-
-	require.Equal(t, http.StatusBadRequest, response.StatusCode)
-
-	var data []byte
-	data, err = io.ReadAll(response.Body)
-	require.NoError(t, err)
-	require.Contains(t, string(data), expectedErrorMessageInBody)
+func requireStatusBadRequest(t *testing.T, expectedErrorMessage string, err error) {
+	var opErr *domain.OperationError
+	require.ErrorAs(t, err, &opErr)
+	require.Equal(t, http.StatusBadRequest, opErr.Status)
+	require.Contains(t, opErr.Message, expectedErrorMessage)
 }
 
 // TestHandleTestConnection ...
@@ -100,8 +91,7 @@ func TestHandleTestConnection(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleTestConnection(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -118,7 +108,7 @@ func testTestConnection(t *testing.T, whService *WebhookService, mockConnectorSe
 		}`, serverURL, apiKey))
 
 	connection := buildConnection()
-	mockConnectorServices.EXPECT().TestConnection(connection).DoAndReturn(func(connection domain.Connection) error {
+	mockConnectorServices.EXPECT().TestConnection(gomock.Any(), connection).DoAndReturn(func(_ context.Context, connection domain.Connection) error {
 		if success {
 			return nil
 		}
@@ -1,10 +1,10 @@
 package digicert_ca_connector
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -26,14 +26,16 @@ type ValidateProductResponse struct {
 func (svc *WebhookService) HandleValidateProduct(c echo.Context) error {
 	req := ValidateProductRequest{}
 	if err := c.Bind(&req); err != nil {
-		zap.L().Error("invalid request, failed to unmarshal json", zap.Error(err))
-		return c.String(http.StatusBadRequest, fmt.Sprintf("failed to unmarshal json: %s", err.Error()))
+		logger(c).Error("invalid request, failed to unmarshal json", zap.Error(err))
+		return unmarshalError(err)
 	}
+	ctx := c.Request().Context()
+	tracing.SetAttributes(ctx, tracing.ProductNameID.String(req.Product.NameID))
 
-	productErrors, err := svc.Options.ValidateProduct(req.Connection, req.ProductName, req.Product)
+	productErrors, err := svc.Options.ValidateProduct(ctx, req.Connection, req.ProductName, req.Product)
 
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return operationError(err.Error(), err)
 	}
 
 	return c.JSON(http.StatusOK, &ValidateProductResponse{
@@ -1,6 +1,7 @@
 package digicert_ca_connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -62,8 +63,7 @@ func TestHandleValidateProduct(t *testing.T) {
 		recorder := httptest.NewRecorder()
 
 		err := whService.HandleValidateProduct(e.NewContext(req, recorder))
-		require.NoError(t, err)
-		requireStatusBadRequest(t, "failed to unmarshal json", recorder.Result())
+		requireStatusBadRequest(t, "failed to unmarshal json", err)
 	})
 }
 
@@ -91,7 +91,7 @@ func testValidateProduct(t *testing.T, whService *WebhookService, mockOptionsSer
 		HashAlgorithm:  productHashAlgorithm,
 		OrganizationID: productOrganizationId,
 	}
-	mockOptionsServices.EXPECT().ValidateProduct(connection, productOptionName, product).DoAndReturn(func(connection domain.Connection, productOptionName string, product domain.Product) ([]domain.ProductError, error) {
+	mockOptionsServices.EXPECT().ValidateProduct(gomock.Any(), connection, productOptionName, product).DoAndReturn(func(_ context.Context, connection domain.Connection, productOptionName string, product domain.Product) ([]domain.ProductError, error) {
 		if success {
 			return nil, nil
 		}
@@ -0,0 +1,23 @@
+package domain
+
+// ApprovalStatus status of the approval request for a submitted order.
+type ApprovalStatus string
+
+const (
+	// ApprovalStatusPending represents pending status for an order approval request.
+	ApprovalStatusPending ApprovalStatus = "PENDING"
+	// ApprovalStatusApproved represents approved status for an order approval request.
+	ApprovalStatusApproved ApprovalStatus = "APPROVED"
+	// ApprovalStatusRejected represents rejected status for an order approval request.
+	ApprovalStatusRejected ApprovalStatus = "REJECTED"
+	// ApprovalStatusFailed represents failed processing of an order approval request.
+	ApprovalStatusFailed ApprovalStatus = "FAILED"
+)
+
+// ApprovalDetails contains details for the approval request of a submitted order
+type ApprovalDetails struct {
+	RequestID    string         `json:"requestId"`
+	Status       ApprovalStatus `json:"status"`
+	Approver     string         `json:"approver"`
+	ErrorMessage string         `json:"errorMessage"`
+}
@@ -14,6 +14,18 @@ const (
 	CertificateStatusFailed CertificateStatus = "FAILED"
 )
 
+// ChainStatus result of building the certificate chain up to a trust anchor.
+type ChainStatus string
+
+const (
+	// ChainStatusVerified represents a chain building to a trust anchor from the certificates returned by the Certificate Authority.
+	ChainStatusVerified ChainStatus = "VERIFIED"
+	// ChainStatusCompleted represents a chain building to a trust anchor once missing intermediates were fetched.
+	ChainStatusCompleted ChainStatus = "COMPLETED"
+	// ChainStatusUnresolved represents a chain not building to any trust anchor.
+	ChainStatusUnresolved ChainStatus = "UNRESOLVED"
+)
+
 // CertificateDetails contains certificate details for the submitted certificate request to a Certificate Authority
 type CertificateDetails struct {
 	ID           string            `json:"id"`
@@ -21,4 +33,6 @@ type CertificateDetails struct {
 	Certificate  string            `json:"certificate"`
 	Chain        []string          `json:"chain"`
 	ErrorMessage string            `json:"errorMessage"`
+	ChainStatus  ChainStatus       `json:"chainStatus,omitempty"`
+	ChainError   string            `json:"chainError,omitempty"`
 }
@@ -8,10 +8,24 @@ type Connection struct {
 
 // Configuration contains needed configuration for connection to a Certificate Authority
 type Configuration struct {
-	ServerURL string `json:"serverUrl"`
+	ServerURL            string `json:"serverUrl"`
+	CheckDcv             bool   `json:"checkDcv"`
+	IdempotencyFieldID   int    `json:"idempotencyFieldId"`
+	DownloadFormat       string `json:"downloadFormat"`
+	ExcludeRootFromChain bool   `json:"excludeRootFromChain"`
 }
 
+const (
+	// DownloadFormatPemAll represents downloading the certificate with its full chain as PEM.
+	DownloadFormatPemAll = "pem_all"
+	// DownloadFormatPemNoRoot represents downloading the certificate with its chain without the root as PEM.
+	DownloadFormatPemNoRoot = "pem_noroot"
+	// DownloadFormatP7b represents downloading the certificate with its chain as a PKCS#7 bundle.
+	DownloadFormatP7b = "p7b"
+)
+
 // Credentials contains needed credentials to authenticate against a Certificate Authority
 type Credentials struct {
-	ApiKey string `json:"apiKey"`
+	ApiKey         string `json:"apiKey"`
+	ApproverApiKey string `json:"approverApiKey"`
 }
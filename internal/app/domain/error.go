@@ -0,0 +1,39 @@
+package domain
+
+// ErrorCode identifies the kind of failure of a connector operation
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest   ErrorCode = "INVALID_REQUEST"
+	ErrorCodeInvalidPayload   ErrorCode = "INVALID_PAYLOAD"
+	ErrorCodeOperationFailed  ErrorCode = "OPERATION_FAILED"
+	ErrorCodeCAUnavailable    ErrorCode = "CA_UNAVAILABLE"
+	ErrorCodeNotFound         ErrorCode = "NOT_FOUND"
+	ErrorCodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrorCodeInternal         ErrorCode = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the body of every failed connector operation
+type ErrorResponse struct {
+	Code          ErrorCode `json:"code"`
+	Message       string    `json:"message"`
+	Retryable     bool      `json:"retryable"`
+	CorrelationID string    `json:"correlationId"`
+}
+
+// OperationError fails a connector operation with the given HTTP status and error response
+type OperationError struct {
+	Status    int
+	Code      ErrorCode
+	Message   string
+	Retryable bool
+	Err       error
+}
+
+func (e *OperationError) Error() string {
+	return e.Message
+}
+
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
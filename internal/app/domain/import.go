@@ -17,9 +17,11 @@ const (
 
 // ImportCertificate contains details for imported certificate
 type ImportCertificate struct {
-	ID          string   `json:"id"`
-	Certificate string   `json:"certificate"`
-	Chain       []string `json:"chain"`
+	ID          string      `json:"id"`
+	Certificate string      `json:"certificate"`
+	Chain       []string    `json:"chain"`
+	ChainStatus ChainStatus `json:"chainStatus,omitempty"`
+	ChainError  string      `json:"chainError,omitempty"`
 }
 
 // ImportDetails contains details for the import
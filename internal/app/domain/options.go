@@ -8,11 +8,69 @@ const (
 	ProductTypeCodeSign ProductType = "CODESIGN"
 )
 
+const (
+	// CsProvisioningMethodClientApp represents code signing key provisioning through the DigiCert client application.
+	CsProvisioningMethodClientApp = "client_app"
+	// CsProvisioningMethodShipToken represents code signing key provisioning on a hardware token shipped by DigiCert.
+	CsProvisioningMethodShipToken = "ship_token"
+	// CsProvisioningMethodExistingToken represents code signing key provisioning on an existing token or HSM.
+	CsProvisioningMethodExistingToken = "existing_token"
+)
+
+const (
+	// DcvMethodEmail represents domain control validation through email sent to domain contacts.
+	DcvMethodEmail = "email"
+	// DcvMethodDnsTxtToken represents domain control validation through a DNS TXT record.
+	DcvMethodDnsTxtToken = "dns-txt-token"
+	// DcvMethodDnsCnameToken represents domain control validation through a DNS CNAME record.
+	DcvMethodDnsCnameToken = "dns-cname-token"
+	// DcvMethodHttpToken represents domain control validation through a file served over HTTP.
+	DcvMethodHttpToken = "http-token"
+)
+
+// ValidationTypeEV is the validation type of extended validation products
+const ValidationTypeEV = "ev"
+
 // Product contains needed product(issuance) data
 type Product struct {
-	OrganizationID int    `json:"organizationId"`
-	HashAlgorithm  string `json:"hashAlgorithm"`
-	NameID         string `json:"nameId"`
+	OrganizationID       int                `json:"organizationId"`
+	HashAlgorithm        string             `json:"hashAlgorithm"`
+	NameID               string             `json:"nameId"`
+	AutoRenew            bool               `json:"autoRenew"`
+	CsProvisioningMethod string             `json:"csProvisioningMethod"`
+	EvApprover           *Contact           `json:"evApprover"`
+	DcvMethod            string             `json:"dcvMethod"`
+	SkipApproval         bool               `json:"skipApproval"`
+	CustomFields         []CustomFieldValue `json:"customFields"`
+	ContainerID          int                `json:"containerId"`
+	Comments             string             `json:"comments"`
+	NoteTemplate         string             `json:"noteTemplate"`
+	AdditionalEmails     []string           `json:"additionalEmails"`
+	ServerPlatformID     int                `json:"serverPlatformId"`
+}
+
+// CustomFieldValue contains the value for a custom order field of the Certificate Authority account
+type CustomFieldValue struct {
+	ID    int    `json:"id"`
+	Value string `json:"value"`
+}
+
+// CustomField contains the definition of a custom order field of the Certificate Authority account
+type CustomField struct {
+	ID       int    `json:"id"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	DataType string `json:"dataType"`
+	Pattern  string `json:"pattern"`
+}
+
+// Contact contains organization contact details
+type Contact struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+	JobTitle  string `json:"jobTitle"`
+	Telephone string `json:"telephone"`
 }
 
 // ProductError represents attribute name and value for invalid product properties
@@ -23,10 +81,30 @@ type ProductError struct {
 
 // ProductDetails contains details related to available product option
 type ProductDetails struct {
-	Hashes               []string `json:"hashAlgorithms"`
-	DefaultHashAlgorithm string   `json:"defaultHashAlgorithm"`
-	NameID               string   `json:"nameId"`
-	Organizations        []int    `json:"organizationIds"`
+	Hashes                []string         `json:"hashAlgorithms"`
+	DefaultHashAlgorithm  string           `json:"defaultHashAlgorithm"`
+	NameID                string           `json:"nameId"`
+	Organizations         []int            `json:"organizationIds"`
+	AllowAutoRenew        bool             `json:"allowAutoRenew"`
+	ValidationType        string           `json:"validationType"`
+	CsProvisioningMethods []string         `json:"csProvisioningMethods"`
+	DcvMethods            []string         `json:"dcvMethods"`
+	CustomFields          []CustomField    `json:"customFields"`
+	Containers            []Container      `json:"containers"`
+	ServerPlatforms       []ServerPlatform `json:"serverPlatforms"`
+}
+
+// ServerPlatform contains a server platform the Certificate Authority can issue SSL certificates for
+type ServerPlatform struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Container contains a Certificate Authority container (division) and the organizations it holds
+type Container struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Organizations []int  `json:"organizationIds"`
 }
 
 // ProductOption contains details related to available product(issuance) option
@@ -16,8 +16,26 @@ const (
 
 // OrderDetails contains order details for the submitted certificate request to a Certificate Authority
 type OrderDetails struct {
-	ID            string      `json:"id"`
-	Status        OrderStatus `json:"status"`
-	CertificateID string      `json:"certificateId"`
-	ErrorMessage  string      `json:"errorMessage"`
+	ID                string             `json:"id"`
+	Status            OrderStatus        `json:"status"`
+	CertificateID     string             `json:"certificateId"`
+	ErrorMessage      string             `json:"errorMessage"`
+	DomainValidations []DomainValidation `json:"domainValidations,omitempty"`
+	DcvCheck          *DcvCheck          `json:"dcvCheck,omitempty"`
+	Approval          *ApprovalDetails   `json:"approval,omitempty"`
+}
+
+// DomainValidation contains domain control validation state of a domain in the submitted order
+type DomainValidation struct {
+	Domain string `json:"domain"`
+	Method string `json:"method"`
+	Status string `json:"status"`
+	Token  string `json:"token"`
+}
+
+// DcvCheck contains the outcome of the last domain control validation check triggered for the submitted order
+type DcvCheck struct {
+	Status       string `json:"status"`
+	CheckedAt    string `json:"checkedAt"`
+	ErrorMessage string `json:"errorMessage"`
 }
@@ -5,6 +5,9 @@ type RevocationStatus string
 const (
 	RevocationStatusSubmitted RevocationStatus = "SUBMITTED"
 	RevocationStatusFailed    RevocationStatus = "FAILED"
+	RevocationStatusPending   RevocationStatus = "PENDING"
+	RevocationStatusRevoked   RevocationStatus = "REVOKED"
+	RevocationStatusRejected  RevocationStatus = "REJECTED"
 )
 
 type CertificateRevocationData struct {
@@ -17,6 +20,11 @@ type CertificateRevocationData struct {
 }
 
 type RevocationDetails struct {
-	Status       RevocationStatus `json:"status"`
-	ErrorMessage *string          `json:"errorMessage"`
+	Status                  RevocationStatus `json:"status"`
+	ErrorMessage            *string          `json:"errorMessage"`
+	RequestID               string           `json:"requestId,omitempty"`
+	RequestIDs              []string         `json:"requestIds,omitempty"`
+	SubmittedCertificateIDs []string         `json:"submittedCertificateIds,omitempty"`
+	RevokedCertificateIDs   []string         `json:"revokedCertificateIds,omitempty"`
+	FailedCertificateIDs    []string         `json:"failedCertificateIds,omitempty"`
 }
@@ -0,0 +1,70 @@
+// Package logging carries the request-scoped logger and keeps secrets out of the logs
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Redacted replaces the values that must never be logged
+const Redacted = "[REDACTED]"
+
+// redactedKeys are the lower-cased JSON keys whose values are redacted, wherever they appear
+var redactedKeys = map[string]bool{
+	"apikey":         true,
+	"approverapikey": true,
+	"csr":            true,
+	"pkcs10request":  true,
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger of the request
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the global logger
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+			return logger
+		}
+	}
+	return zap.L()
+}
+
+// RedactJSON returns the JSON document with the API keys and CSRs redacted. Anything that is not JSON is
+// redacted as a whole, since it cannot be inspected
+func RedactJSON(data []byte) string {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return Redacted
+	}
+	redacted, err := json.Marshal(redact(document))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if redactedKeys[strings.ToLower(key)] {
+				v[key] = Redacted
+			} else {
+				v[key] = redact(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redact(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "credentials",
+			data:     `{"connection":{"configuration":{"serverUrl":"https://digicert"},"credentials":{"apiKey":"secret","approverApiKey":"secret"}}}`,
+			expected: `{"connection":{"configuration":{"serverUrl":"https://digicert"},"credentials":{"apiKey":"[REDACTED]","approverApiKey":"[REDACTED]"}}}`,
+		},
+		{
+			name:     "csr",
+			data:     `{"pkcs10Request":"-----BEGIN CERTIFICATE REQUEST-----","orders":[{"certificate":{"CSR":"-----BEGIN CERTIFICATE REQUEST-----"}}]}`,
+			expected: `{"orders":[{"certificate":{"CSR":"[REDACTED]"}}],"pkcs10Request":"[REDACTED]"}`,
+		},
+		{
+			name:     "notJSON",
+			data:     `apiKey=secret`,
+			expected: Redacted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, RedactJSON([]byte(tt.data)))
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, zap.L(), FromContext(context.Background()))
+
+	logger := zap.NewNop()
+	require.Same(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	requestUri       = "/request/%s"
+	requestStatusUri = "/request/%s/status"
+)
+
+type orderRequest struct {
+	ID     int    `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+type digicertUser struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+}
+
+type digicertRequestDetails struct {
+	ID     int    `json:"id"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	// Approver is the user the request is assigned to, if any. Otherwise any approver of the container can
+	// process it
+	Approver  *digicertUser `json:"approver"`
+	Container *container    `json:"container"`
+	// Processor is only set once the request has been processed
+	Processor *digicertUser `json:"processor"`
+}
+
+type updateRequestStatusBody struct {
+	Status           string `json:"status"`
+	ProcessorComment string `json:"processor_comment,omitempty"`
+}
+
+// ProcessApproval will approve or reject a pending order request on behalf of the configured approver
+func (cs *Certificate) ProcessApproval(ctx context.Context, connection domain.Connection, requestID string, approve bool, comment string) (*domain.ApprovalDetails, error) {
+	if connection.Credentials.ApproverApiKey == "" {
+		return nil, fmt.Errorf("no approver API key configured for the connection")
+	}
+	approverConnection := connection
+	approverConnection.Credentials.ApiKey = connection.Credentials.ApproverApiKey
+
+	status := domain.ApprovalStatusRejected
+	requestBody := updateRequestStatusBody{
+		Status:           "rejected",
+		ProcessorComment: comment,
+	}
+	if approve {
+		status = domain.ApprovalStatusApproved
+		requestBody.Status = "approved"
+	}
+
+	_, err := executeRequest(ctx, approverConnection, requestBody, fmt.Sprintf(requestStatusUri, requestID), http.MethodPut)
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("failed to process DigiCert request '%s'", requestID), zap.Error(err))
+		return &domain.ApprovalDetails{
+			RequestID:    requestID,
+			Status:       domain.ApprovalStatusFailed,
+			ErrorMessage: fmt.Sprintf("failed to update request status on DigiCert CA server: %s", err.Error()),
+		}, nil
+	}
+
+	return &domain.ApprovalDetails{
+		RequestID: requestID,
+		Status:    status,
+	}, nil
+}
+
+// pendingApproval looks up the pending request of an order waiting for approval and who it waits on
+func pendingApproval(ctx context.Context, connection domain.Connection, requests []orderRequest) *domain.ApprovalDetails {
+	var pending *orderRequest
+	for i := range requests {
+		if requests[i].Status == "pending" {
+			pending = &requests[i]
+			break
+		}
+	}
+	if pending == nil {
+		return nil
+	}
+
+	approval := &domain.ApprovalDetails{
+		RequestID: strconv.Itoa(pending.ID),
+		Status:    domain.ApprovalStatusPending,
+	}
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(requestUri, approval.RequestID), http.MethodGet)
+	if err != nil {
+		approval.ErrorMessage = fmt.Sprintf("failed to retrieve request details from DigiCert CA server: %s", err.Error())
+		return approval
+	}
+
+	details := digicertRequestDetails{}
+	err = json.Unmarshal(resp.Body(), &details)
+	if err != nil {
+		approval.ErrorMessage = fmt.Sprintf("failed to retrieve request details from DigiCert CA server: %s", err.Error())
+		return approval
+	}
+	approval.Approver = details.approvers()
+	return approval
+}
+
+// approvers names who can process a pending request: the assigned approver, or else the approvers of its
+// container
+func (d digicertRequestDetails) approvers() string {
+	switch {
+	case d.Approver != nil:
+		return strings.TrimSpace(fmt.Sprintf("%s %s <%s>", d.Approver.FirstName, d.Approver.LastName, d.Approver.Email))
+	case d.Container != nil && d.Container.Name != "":
+		return fmt.Sprintf("approvers of container %s", d.Container.Name)
+	case d.Container != nil:
+		return fmt.Sprintf("approvers of container %d", d.Container.ID)
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	approverApiKey = "approverApiKey"
+)
+
+// TestProcessApproval ...
+func TestProcessApproval(t *testing.T) {
+	t.Run("approve", func(t *testing.T) {
+		testProcessApproval(t, true, http.StatusNoContent)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		testProcessApproval(t, false, http.StatusNoContent)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		testProcessApproval(t, true, http.StatusForbidden)
+	})
+
+	t.Run("noApproverKey", func(t *testing.T) {
+		_, err := NewCertificateService().ProcessApproval(context.Background(), buildConnection(), "42", true, "")
+		require.Error(t, err)
+	})
+}
+
+// TestCheckOrderNeedsApproval ...
+func TestCheckOrderNeedsApproval(t *testing.T) {
+	t.Run("assignedApprover", func(t *testing.T) {
+		testCheckOrderNeedsApproval(t, digicertRequestDetails{
+			ID:     42,
+			Type:   "new_request",
+			Status: "pending",
+			Approver: &digicertUser{
+				FirstName: "Jane",
+				LastName:  "Doe",
+				Email:     "jane.doe@example.com",
+			},
+		}, "Jane Doe <jane.doe@example.com>")
+	})
+
+	t.Run("containerApprovers", func(t *testing.T) {
+		// a pending request has no processor yet
+		testCheckOrderNeedsApproval(t, digicertRequestDetails{
+			ID:        42,
+			Type:      "new_request",
+			Status:    "pending",
+			Container: &container{ID: 7, Name: "Engineering"},
+		}, "approvers of container Engineering")
+	})
+}
+
+func testCheckOrderNeedsApproval(t *testing.T, request digicertRequestDetails, approver string) {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf(orderCertificateUri, "1234"),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, digiCertOrderDetails{
+				ID:     1234,
+				Status: "needs_approval",
+				Requests: []orderRequest{
+					{
+						ID:     42,
+						Type:   "new_request",
+						Status: "pending",
+					},
+				},
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", fmt.Sprintf(requestUri, "42"),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, request)
+		},
+	)
+
+	details, err := NewCertificateService().CheckOrder(context.Background(), connection, "1234")
+	require.NoError(t, err)
+	require.Equal(t, domain.OrderStatusProcessing, details.Status)
+	require.Equal(t, &domain.ApprovalDetails{
+		RequestID: "42",
+		Status:    domain.ApprovalStatusPending,
+		Approver:  approver,
+	}, details.Approval)
+	require.Equal(t, "waiting for approval of request 42", details.ErrorMessage)
+}
+
+func testProcessApproval(t *testing.T, approve bool, httpStatus int) {
+	connection := buildConnection()
+	connection.Credentials.ApproverApiKey = approverApiKey
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("PUT", serverURL+fmt.Sprintf(requestStatusUri, "42"),
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, approverApiKey, req.Header.Get("X-DC-DEVKEY"))
+
+			data, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+			body := &updateRequestStatusBody{}
+			err = json.Unmarshal(data, body)
+			assert.NoError(t, err)
+			if approve {
+				assert.Equal(t, "approved", body.Status)
+			} else {
+				assert.Equal(t, "rejected", body.Status)
+			}
+			assert.Equal(t, "processed by automation", body.ProcessorComment)
+
+			if httpStatus == http.StatusNoContent {
+				return httpmock.NewStringResponse(httpStatus, ""), nil
+			}
+			return httpmock.NewJsonResponse(httpStatus, "{\"errors\": [{\"code\": \"access_denied\"}]}")
+		},
+	)
+
+	details, err := NewCertificateService().ProcessApproval(context.Background(), connection, "42", approve, "processed by automation")
+	require.NoError(t, err)
+	require.Equal(t, "42", details.RequestID)
+	switch {
+	case httpStatus != http.StatusNoContent:
+		require.Equal(t, domain.ApprovalStatusFailed, details.Status)
+		require.NotEmpty(t, details.ErrorMessage)
+	case approve:
+		require.Equal(t, domain.ApprovalStatusApproved, details.Status)
+	default:
+		require.Equal(t, domain.ApprovalStatusRejected, details.Status)
+	}
+}
@@ -1,29 +1,42 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
 	orderCertificateUri                     = "/order/certificate/%s"
-	downloadCertificateUri                  = "/certificate/%s/download/format/pem_all"
 	retrieveCertificatesUri                 = "/order/certificate?%sfilters[status]=issued&limit=%d&offset=%s&sort=order_id"
 	revokeCertificateUri                    = "/certificate/%s/revoke"
 	retrieveCertificatesProductNameIdFilter = "filters[product_name_id]=%s&"
 	digicertDateFormat                      = "2006-01-02"
+	// DefaultImportConcurrency is how many certificates of an import batch are downloaded at the same time, one
+	// after the other unless configured otherwise
+	DefaultImportConcurrency = 1
 )
 
+// certificatePendingErrorCodes are returned by DigiCert for certificates that are not issued yet
+var certificatePendingErrorCodes = []string{"cert_unavailable_processing", "cert_unavailable_pending"}
+
+// certificateRevokedErrorCodes are returned by DigiCert for certificates that can no longer be downloaded because they were revoked
+var certificateRevokedErrorCodes = []string{"cert_unavailable_revoked", "cert_revoked"}
+
 type serverPlatform struct {
 	ID int `json:"id"`
 }
@@ -34,17 +47,43 @@ type certificate struct {
 	Csr                  string         `json:"csr"`
 	ServerPlatform       serverPlatform `json:"server_platform"`
 	SignatureHash        string         `json:"signature_hash"`
-	CsProvisioningMethod string         `json:"cs_provisioning_method"`
+	CsProvisioningMethod string         `json:"cs_provisioning_method,omitempty"`
+}
+
+type digicertContact struct {
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Email       string `json:"email"`
+	JobTitle    string `json:"job_title,omitempty"`
+	Telephone   string `json:"telephone,omitempty"`
+	ContactType string `json:"contact_type"`
+}
+
+type customField struct {
+	MetadataID int    `json:"metadata_id"`
+	Value      string `json:"value"`
 }
 
 type digicertOrganization struct {
-	ID int `json:"id"`
+	ID       int               `json:"id"`
+	Contacts []digicertContact `json:"contacts,omitempty"`
 }
 
 type newCertificateRequestBody struct {
 	Certificate          certificate          `json:"certificate"`
 	Organization         digicertOrganization `json:"organization"`
+	Container            *digicertContainer   `json:"container,omitempty"`
 	CustomExpirationDate string               `json:"custom_expiration_date"`
+	AutoRenew            int                  `json:"auto_renew"`
+	DcvMethod            string               `json:"dcv_method,omitempty"`
+	SkipApproval         bool                 `json:"skip_approval,omitempty"`
+	CustomFields         []customField        `json:"custom_fields,omitempty"`
+	Comments             string               `json:"comments,omitempty"`
+	AdditionalEmails     []string             `json:"additional_emails,omitempty"`
+}
+
+type digicertContainer struct {
+	ID int `json:"id"`
 }
 
 type newRevokeCertificateRequestBody struct {
@@ -56,10 +95,25 @@ type certificateChain struct {
 	Pem string `json:"pem"`
 }
 
+type dcvToken struct {
+	Token          string `json:"token"`
+	Status         string `json:"status"`
+	ExpirationDate string `json:"expiration_date"`
+}
+
+type orderDomain struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	DcvStatus string    `json:"dcv_status"`
+	DcvToken  *dcvToken `json:"dcv_token"`
+}
+
 type digiCertRequestCertificateResponse struct {
 	ID               int                `json:"id"`
 	CertificateID    int                `json:"certificate_id"`
 	CertificateChain []certificateChain `json:"certificate_chain"`
+	Domains          []orderDomain      `json:"domains"`
+	DcvRandomValue   string             `json:"dcv_random_value"`
 }
 
 type orderCertificate struct {
@@ -68,9 +122,14 @@ type orderCertificate struct {
 }
 
 type digiCertOrderDetails struct {
-	ID          int               `json:"id"`
-	Status      string            `json:"status"`
-	Certificate *orderCertificate `json:"certificate"`
+	ID             int               `json:"id"`
+	Status         string            `json:"status"`
+	Certificate    *orderCertificate `json:"certificate"`
+	DcvMethod      string            `json:"dcv_method"`
+	DcvRandomValue string            `json:"dcv_random_value"`
+	Domains        []orderDomain     `json:"domains"`
+	Requests       []orderRequest    `json:"requests"`
+	CustomFields   []customField     `json:"custom_fields"`
 }
 
 type page struct {
@@ -91,15 +150,63 @@ type digicertRevokeCertificateResponse struct {
 
 // Certificate service responsible for certificate related operations
 type Certificate struct {
+	dcvChecks        *dcvCheckThrottle
+	idempotency      IdempotencyStore
+	idempotencyLocks *keyedMutex
+	chainVerifier    *ChainVerifier
+	importWorkers    int
+}
+
+// CertificateOption configures the certificate service
+type CertificateOption func(*Certificate)
+
+// WithIdempotencyStore sets the store remembering submitted certificate requests
+func WithIdempotencyStore(store IdempotencyStore) CertificateOption {
+	return func(cs *Certificate) {
+		cs.idempotency = store
+	}
+}
+
+// WithChainVerifier sets the verifier checking that downloaded chains build to a trust anchor
+func WithChainVerifier(verifier *ChainVerifier) CertificateOption {
+	return func(cs *Certificate) {
+		cs.chainVerifier = verifier
+	}
+}
+
+// WithDcvCheckInterval sets the minimum time between two DCV checks triggered for the same order
+func WithDcvCheckInterval(interval time.Duration) CertificateOption {
+	return func(cs *Certificate) {
+		cs.dcvChecks = newDcvCheckThrottle(interval)
+	}
+}
+
+// WithImportConcurrency sets how many certificates of an import batch are downloaded at the same time
+func WithImportConcurrency(workers int) CertificateOption {
+	return func(cs *Certificate) {
+		if workers > 0 {
+			cs.importWorkers = workers
+		}
+	}
 }
 
 // NewCertificateService will return a new webhook certificate service
-func NewCertificateService() *Certificate {
-	return &Certificate{}
+func NewCertificateService(opts ...CertificateOption) *Certificate {
+	cs := &Certificate{
+		dcvChecks:        newDcvCheckThrottle(DefaultDcvCheckInterval),
+		idempotency:      NewMemoryIdempotencyStore(DefaultIdempotencyWindow),
+		idempotencyLocks: newKeyedMutex(),
+		chainVerifier:    NewChainVerifier(nil, nil),
+		importWorkers:    DefaultImportConcurrency,
+	}
+	for _, opt := range opts {
+		opt(cs)
+	}
+	return cs
 }
 
 // RequestCertificate will request certificate from a Certificate Authority
-func (cs *Certificate) RequestCertificate(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
+func (cs *Certificate) RequestCertificate(ctx context.Context, connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string, validitySeconds int, productDetails *domain.ProductDetails) (*domain.CertificateDetails, *domain.OrderDetails, error) {
 
 	pemBlock, _ := pem.Decode([]byte(pkcs10Request))
 	csr, err := x509.ParseCertificateRequest(pemBlock.Bytes)
@@ -124,24 +231,113 @@ func (cs *Certificate) RequestCertificate(connection domain.Connection, pkcs10Re
 			DnsNames:   csr.DNSNames,
 			Csr:        pkcs10NoNewLines,
 			ServerPlatform: serverPlatform{
-				ID: -1,
+				ID: serverPlatformID(product),
 			},
-			SignatureHash: product.HashAlgorithm,
+			SignatureHash:        product.HashAlgorithm,
+			CsProvisioningMethod: product.CsProvisioningMethod,
 		},
 		Organization: digicertOrganization{
 			ID: product.OrganizationID,
 		},
 		CustomExpirationDate: time.Now().Add(time.Second * time.Duration(validitySeconds)).Format(digicertDateFormat),
+		DcvMethod:            product.DcvMethod,
+		SkipApproval:         product.SkipApproval,
+		Comments:             product.Comments,
+		AdditionalEmails:     product.AdditionalEmails,
+	}
+	if product.AutoRenew {
+		requestBody.AutoRenew = 1
+	}
+	if product.ContainerID != 0 {
+		requestBody.Container = &digicertContainer{
+			ID: product.ContainerID,
+		}
+	}
+	for _, field := range product.CustomFields {
+		requestBody.CustomFields = append(requestBody.CustomFields, customField{
+			MetadataID: field.ID,
+			Value:      field.Value,
+		})
 	}
+	if product.EvApprover != nil {
+		requestBody.Organization.Contacts = append(requestBody.Organization.Contacts, digicertContact{
+			FirstName:   product.EvApprover.FirstName,
+			LastName:    product.EvApprover.LastName,
+			Email:       product.EvApprover.Email,
+			JobTitle:    product.EvApprover.JobTitle,
+			Telephone:   product.EvApprover.Telephone,
+			ContactType: "ev_approver",
+		})
+	}
+
+	// a retried webhook call must not place a second order, so requests are deduplicated by idempotency key
+	key := idempotencyKey(connection, pkcs10Request, product, productOptionName)
+	unlock := cs.idempotencyLocks.lock(key)
+	defer unlock()
 
-	resp, err := executeRequest(connection, requestBody, fmt.Sprintf(orderCertificateUri, productDetails.NameID), http.MethodPost)
+	record, err := cs.idempotency.Get(key)
+	if err != nil {
+		zap.L().Warn("failed to read idempotency store", zap.Error(err))
+	} else if record != nil && !record.Uncertain {
+		zap.L().Info("returning outcome of previously submitted certificate request", zap.String("idempotencyKey", key))
+		return record.CertificateDetails, record.OrderDetails, nil
+	}
+
+	if connection.Configuration.IdempotencyFieldID != 0 {
+		if orderDetails := cs.findTaggedOrder(ctx, connection, commonName, key); orderDetails != nil {
+			zap.L().Info("returning previously submitted order tagged with idempotency key", zap.String("idempotencyKey", key), zap.String("orderId", orderDetails.ID))
+			cs.rememberRequest(key, nil, orderDetails, false)
+			return nil, orderDetails, nil
+		}
+	}
+	// the order of an uncertain request may not be found yet, it is not placed again while remembered
+	if record != nil {
+		zap.L().Info("returning outcome of previously submitted certificate request that may have reached DigiCert", zap.String("idempotencyKey", key))
+		return record.CertificateDetails, record.OrderDetails, nil
+	}
+
+	if connection.Configuration.IdempotencyFieldID != 0 {
+		requestBody.CustomFields = append(requestBody.CustomFields, customField{
+			MetadataID: connection.Configuration.IdempotencyFieldID,
+			Value:      key,
+		})
+	}
+
+	note := orderNote(product, commonName, productOptionName)
+	certificateDetails, orderDetails, reached := cs.submitCertificateRequest(ctx, connection, requestBody, product, productDetails, note)
+	failed := certificateDetails != nil && certificateDetails.Status == domain.CertificateStatusFailed
+	// a failed request is submitted again on retry only when DigiCert refused it, it may have placed the order otherwise
+	if !failed || reached {
+		cs.rememberRequest(key, certificateDetails, orderDetails, failed)
+	}
+	return certificateDetails, orderDetails, nil
+}
+
+func (cs *Certificate) rememberRequest(key string, certificateDetails *domain.CertificateDetails, orderDetails *domain.OrderDetails, uncertain bool) {
+	err := cs.idempotency.Put(key, IdempotencyRecord{
+		CreatedAt:          time.Now(),
+		CertificateDetails: certificateDetails,
+		OrderDetails:       orderDetails,
+		Uncertain:          uncertain,
+	})
+	if err != nil {
+		zap.L().Warn("failed to write idempotency store", zap.Error(err))
+	}
+}
+
+// submitCertificateRequest places the order on DigiCert and converts the response to certificate or order details.
+// reached tells whether the request may have reached DigiCert, which is only ruled out when DigiCert refused it
+func (cs *Certificate) submitCertificateRequest(ctx context.Context, connection domain.Connection, requestBody newCertificateRequestBody, product domain.Product, productDetails *domain.ProductDetails, note string) (*domain.CertificateDetails, *domain.OrderDetails, bool) {
+	resp, err := executeRequest(ctx, connection, requestBody, fmt.Sprintf(orderCertificateUri, productDetails.NameID), http.MethodPost)
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("failed to request certificate from DigiCert CA using product name id: '%s'",
 			productDetails.NameID), zap.Error(err))
+		var digicertErr *digicertError
+		refused := errors.As(err, &digicertErr) && digicertErr.StatusCode >= http.StatusBadRequest && digicertErr.StatusCode < http.StatusInternalServerError
 		return &domain.CertificateDetails{
 			Status:       domain.CertificateStatusFailed,
 			ErrorMessage: fmt.Sprintf("failed to request certificate from DigiCert CA server: %s", err.Error()),
-		}, nil, nil
+		}, nil, !refused
 	}
 
 	digicertResponse := digiCertRequestCertificateResponse{}
@@ -151,7 +347,11 @@ func (cs *Certificate) RequestCertificate(connection domain.Connection, pkcs10Re
 		return &domain.CertificateDetails{
 			Status:       domain.CertificateStatusFailed,
 			ErrorMessage: fmt.Sprintf("failed request certificate from DigiCert CA server: %s", err.Error()),
-		}, nil, nil
+		}, nil, true
+	}
+
+	if digicertResponse.ID != 0 {
+		addOrderNote(ctx, connection, digicertResponse.ID, note)
 	}
 
 	if digicertResponse.CertificateChain != nil || digicertResponse.CertificateID != 0 {
@@ -175,25 +375,26 @@ func (cs *Certificate) RequestCertificate(connection domain.Connection, pkcs10Re
 		} else {
 			certificateDetails.Status = domain.CertificateStatusRequested
 		}
-		return certificateDetails, nil, nil
+		return certificateDetails, nil, true
 	}
 
 	orderDetails := &domain.OrderDetails{
-		ID:     strconv.Itoa(digicertResponse.ID),
-		Status: domain.OrderStatusProcessing,
+		ID:                strconv.Itoa(digicertResponse.ID),
+		Status:            domain.OrderStatusProcessing,
+		DomainValidations: domainValidations(product.DcvMethod, digicertResponse.DcvRandomValue, digicertResponse.Domains),
 	}
 	if digicertResponse.CertificateID != 0 {
 		orderDetails.CertificateID = strconv.Itoa(digicertResponse.CertificateID)
 		orderDetails.Status = domain.OrderStatusCompleted
 	}
 
-	return nil, orderDetails, nil
+	return nil, orderDetails, true
 }
 
 // CheckOrder will check order details for submitted certificate request
-func (cs *Certificate) CheckOrder(connection domain.Connection, id string) (*domain.OrderDetails, error) {
+func (cs *Certificate) CheckOrder(ctx context.Context, connection domain.Connection, id string) (*domain.OrderDetails, error) {
 
-	resp, err := executeRequest(connection, nil, fmt.Sprintf(orderCertificateUri, id), http.MethodGet)
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(orderCertificateUri, id), http.MethodGet)
 	if err != nil {
 		return nil, err
 	}
@@ -204,57 +405,108 @@ func (cs *Certificate) CheckOrder(connection domain.Connection, id string) (*dom
 		return nil, err
 	}
 
+	orderDetails := mapOrderDetails(id, digicertOrderDetails)
+	if digicertOrderDetails.Status == "pending" && connection.Configuration.CheckDcv && hasPendingTokenValidation(orderDetails.DomainValidations) {
+		var dcvResponse *digicertCheckDcvResponse
+		orderDetails.DcvCheck, dcvResponse = cs.checkDcv(ctx, connection, id)
+		if dcvResponse != nil && dcvResponse.OrderStatus == "issued" && dcvResponse.CertificateID > 0 {
+			orderDetails.Status = domain.OrderStatusCompleted
+			orderDetails.CertificateID = strconv.Itoa(dcvResponse.CertificateID)
+			orderDetails.DomainValidations = nil
+			orderDetails.ErrorMessage = ""
+		}
+	}
+	if digicertOrderDetails.Status == "needs_approval" {
+		orderDetails.Approval = pendingApproval(ctx, connection, digicertOrderDetails.Requests)
+		if orderDetails.Approval != nil {
+			orderDetails.ErrorMessage = fmt.Sprintf("waiting for approval of request %s", orderDetails.Approval.RequestID)
+		}
+	}
+
+	return &orderDetails, nil
+}
+
+// mapOrderDetails maps the DigiCert order details as they are, without asking DigiCert for anything else
+func mapOrderDetails(id string, digicertOrderDetails digiCertOrderDetails) domain.OrderDetails {
 	orderDetails := domain.OrderDetails{
 		ID:     id,
 		Status: domain.OrderStatusFailed,
 	}
 	if digicertOrderDetails.Status == "issued" {
 		orderDetails.Status = domain.OrderStatusCompleted
-		orderDetails.CertificateID = strconv.Itoa(digicertOrderDetails.Certificate.ID)
 	} else if digicertOrderDetails.Status == "pending" || digicertOrderDetails.Status == "needs_approval" || digicertOrderDetails.Status == "processing" {
 		orderDetails.Status = domain.OrderStatusProcessing
 	}
+	if digicertOrderDetails.Status == "pending" {
+		orderDetails.DomainValidations = domainValidations(digicertOrderDetails.DcvMethod, digicertOrderDetails.DcvRandomValue, digicertOrderDetails.Domains)
+		orderDetails.ErrorMessage = pendingDomainValidationMessage(orderDetails.DomainValidations)
+	}
 	if digicertOrderDetails.Certificate != nil && digicertOrderDetails.Certificate.ID > 0 {
 		orderDetails.CertificateID = strconv.Itoa(digicertOrderDetails.Certificate.ID)
 	}
-
-	return &orderDetails, nil
+	return orderDetails
 }
 
 // CheckCertificate will check certificate details for submitted certificate request
-func (cs *Certificate) CheckCertificate(connection domain.Connection, id string) (*domain.CertificateDetails, error) {
-
-	resp, err := executeRequest(connection, nil, fmt.Sprintf(downloadCertificateUri, id), http.MethodGet)
-	if err != nil {
-		return nil, err
-	}
+func (cs *Certificate) CheckCertificate(ctx context.Context, connection domain.Connection, id string) (*domain.CertificateDetails, error) {
 
 	certDetails := domain.CertificateDetails{
 		ID:     id,
 		Status: domain.CertificateStatusRequested,
 	}
 
-	if resp.Body() != nil {
-		cert, chain, err := parseCertificateData(resp.String())
-		if err != nil {
-			return nil, err
+	uri, err := downloadCertificatePath(connection.Configuration, id)
+	if err != nil {
+		certDetails.Status = domain.CertificateStatusFailed
+		certDetails.ErrorMessage = err.Error()
+		return &certDetails, nil
+	}
+
+	resp, err := executeRequest(ctx, connection, nil, uri, http.MethodGet)
+	if err != nil {
+		var digicertErr *digicertError
+		switch {
+		case errors.As(err, &digicertErr) && (digicertErr.StatusCode == http.StatusNotFound || digicertErr.hasCode(certificatePendingErrorCodes...)):
+			zap.L().Info("certificate is not yet available for download", zap.String("certificateId", id))
+		case errors.As(err, &digicertErr) && digicertErr.hasCode(certificateRevokedErrorCodes...):
+			certDetails.Status = domain.CertificateStatusFailed
+			certDetails.ErrorMessage = "certificate has been revoked"
+		default:
+			zap.L().Error("failed to download certificate from DigiCert CA", zap.String("certificateId", id), zap.Error(err))
+			certDetails.Status = domain.CertificateStatusFailed
+			certDetails.ErrorMessage = fmt.Sprintf("failed to download certificate from DigiCert CA server: %s", err.Error())
 		}
-		certDetails.Status = domain.CertificateStatusIssued
-		certDetails.Certificate = cert
-		certDetails.Chain = chain
+		return &certDetails, nil
+	}
+
+	if len(bytes.TrimSpace(resp.Body())) == 0 {
+		return &certDetails, nil
+	}
+
+	cert, chain, result, err := cs.verifiedCertificateData(resp.String(), connection.Configuration)
+	if err != nil {
+		zap.L().Error("failed to parse downloaded certificate", zap.String("certificateId", id), zap.Error(err))
+		certDetails.Status = domain.CertificateStatusFailed
+		certDetails.ErrorMessage = fmt.Sprintf("failed to parse certificate downloaded from DigiCert CA server: %s", err.Error())
+		return &certDetails, nil
 	}
+	certDetails.Status = domain.CertificateStatusIssued
+	certDetails.Certificate = cert
+	certDetails.Chain = chain
+	certDetails.ChainStatus = result.status
+	certDetails.ChainError = result.errorMessage()
 
 	return &certDetails, nil
 }
 
 // RetrieveCertificates will retrieve certificates available for import in TLSPC, from a Certificate Authority
-func (cs *Certificate) RetrieveCertificates(connection domain.Connection, importOption domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error) {
+func (cs *Certificate) RetrieveCertificates(ctx context.Context, connection domain.Connection, importOption domain.ImportOption, configuration domain.ImportConfiguration, startCursor string, batchSize int) (*domain.ImportDetails, error) {
 
 	var filters = ""
 	if importOption.Settings.NameID != "" {
 		filters = fmt.Sprintf(retrieveCertificatesProductNameIdFilter, importOption.Settings.NameID)
 	}
-	resp, err := executeRequest(connection, nil, fmt.Sprintf(retrieveCertificatesUri, filters, batchSize, startCursor), http.MethodGet)
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(retrieveCertificatesUri, filters, batchSize, startCursor), http.MethodGet)
 	if err != nil {
 		return nil, err
 	}
@@ -271,7 +523,7 @@ func (cs *Certificate) RetrieveCertificates(connection domain.Connection, import
 		status = domain.ImportStatusCompleted
 	}
 
-	var certificates []domain.ImportCertificate
+	var orders []digiCertOrderDetails
 	var now = time.Now()
 	for _, order := range orderDetailsSearchResponse.Orders {
 		dateValue, err := time.Parse(digicertDateFormat, order.Certificate.ValidTill)
@@ -282,26 +534,15 @@ func (cs *Certificate) RetrieveCertificates(connection domain.Connection, import
 		if dateValue.Before(now) && !configuration.IncludeExpiredCertificates {
 			continue
 		}
+		orders = append(orders, order)
+	}
 
-		resp, err = executeRequest(connection, nil, fmt.Sprintf("/certificate/%d/download/format/pem_all", order.Certificate.ID), http.MethodGet)
-		if err != nil {
-			return nil, err
-		}
-
-		if resp.Body() != nil {
-			cert, chain, err := parseCertificateData(resp.String())
-			if err != nil {
-				continue
-			}
-
-			certificates = append(certificates, domain.ImportCertificate{
-				ID:          strconv.Itoa(order.Certificate.ID),
-				Certificate: cert,
-				Chain:       chain,
-			})
-		}
+	certificates, err := cs.downloadImportCertificates(ctx, connection, orders)
+	if err != nil {
+		return nil, err
 	}
 
+	importBatchCertificates.Observe(float64(len(certificates)))
 	return &domain.ImportDetails{
 		ImportStatus:               status,
 		LastProcessedCertificateID: strconv.Itoa(lastOffset),
@@ -309,13 +550,74 @@ func (cs *Certificate) RetrieveCertificates(connection domain.Connection, import
 	}, nil
 }
 
-func (cs *Certificate) RevokeCertificate(connection domain.Connection, serialNumber string, reasonCode int) (*domain.RevocationDetails, error) {
+// downloadImportCertificates downloads the certificates of the orders, importWorkers at a time, keeping the order
+// of the orders. The certificates that cannot be parsed are skipped. Once a download fails no other is started
+// and the ones in flight are cancelled, the batch failing as a whole
+func (cs *Certificate) downloadImportCertificates(ctx context.Context, connection domain.Connection, orders []digiCertOrderDetails) ([]domain.ImportCertificate, error) {
+	downloaded := make([]*domain.ImportCertificate, len(orders))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(cs.importWorkers)
+	for i, order := range orders {
+		i, order := i, order
+		group.Go(func() error {
+			if groupCtx.Err() != nil {
+				return nil
+			}
+			var err error
+			downloaded[i], err = cs.downloadImportCertificate(groupCtx, connection, order)
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var certificates []domain.ImportCertificate
+	for i := range orders {
+		if downloaded[i] != nil {
+			certificates = append(certificates, *downloaded[i])
+		}
+	}
+	return certificates, nil
+}
+
+func (cs *Certificate) downloadImportCertificate(ctx context.Context, connection domain.Connection, order digiCertOrderDetails) (*domain.ImportCertificate, error) {
+	uri, err := downloadCertificatePath(connection.Configuration, strconv.Itoa(order.Certificate.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := executeRequest(ctx, connection, nil, uri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body() == nil {
+		return nil, nil
+	}
+
+	cert, chain, result, err := cs.verifiedCertificateData(resp.String(), connection.Configuration)
+	if err != nil {
+		return nil, nil
+	}
+	return &domain.ImportCertificate{
+		ID:          strconv.Itoa(order.Certificate.ID),
+		Certificate: cert,
+		Chain:       chain,
+		ChainStatus: result.status,
+		ChainError:  result.errorMessage(),
+	}, nil
+}
+
+func (cs *Certificate) RevokeCertificate(ctx context.Context, connection domain.Connection, serialNumber string, reasonCode int) (*domain.RevocationDetails, error) {
 	requestBody := newRevokeCertificateRequestBody{
 		Reason:  revocationReasonCodeToString(reasonCode),
 		Comment: "",
 	}
 
-	resp, err := executeRequest(connection, requestBody, fmt.Sprintf(revokeCertificateUri, serialNumber), http.MethodPut)
+	requestID, err := submitRevocation(ctx, connection, fmt.Sprintf(revokeCertificateUri, serialNumber), requestBody)
 	if err != nil {
 		zap.L().Error(fmt.Sprintf("failed to submit certificate revocation request to DigiCert CA using serial number: '%s'",
 			serialNumber), zap.Error(err))
@@ -326,47 +628,71 @@ func (cs *Certificate) RevokeCertificate(connection domain.Connection, serialNum
 		}, nil
 	}
 
+	return &domain.RevocationDetails{
+		Status:    domain.RevocationStatusSubmitted,
+		RequestID: strconv.Itoa(requestID),
+	}, nil
+}
+
+// submitRevocation submits the revocation request to the certificate or order revoke URI and returns the ID of
+// the DigiCert request
+func submitRevocation(ctx context.Context, connection domain.Connection, uriPath string, requestBody newRevokeCertificateRequestBody) (int, error) {
+	resp, err := executeRequest(ctx, connection, requestBody, uriPath, http.MethodPut)
+	if err != nil {
+		return 0, err
+	}
+
 	digicertResponse := digicertRevokeCertificateResponse{}
 	err = json.Unmarshal(resp.Body(), &digicertResponse)
 	if err != nil {
 		zap.L().Error("failed to unmarshal certificate revocation response.", zap.Error(err))
-		errMessage := fmt.Sprintf("failed to submit certificate revocation request to DigiCert CA server for certificate with serial number %s: %s", serialNumber, err.Error())
-		return &domain.RevocationDetails{
-			Status:       domain.RevocationStatusFailed,
-			ErrorMessage: &errMessage,
-		}, nil
+		return 0, err
 	}
 
 	if digicertResponse.Status != "submitted" {
-		errMessage := fmt.Sprintf("failed to submit certificate revocation request to DigiCert CA server for certificate with serial number %s: %s", serialNumber, err.Error())
-		return &domain.RevocationDetails{
-			Status:       domain.RevocationStatusFailed,
-			ErrorMessage: &errMessage,
-		}, nil
+		return 0, fmt.Errorf("unexpected revocation request status: %s", digicertResponse.Status)
 	}
-
-	return &domain.RevocationDetails{
-		Status: domain.RevocationStatusSubmitted,
-	}, nil
+	return digicertResponse.ID, nil
 }
 
-func parseCertificateData(pemData string) (string, []string, error) {
-
-	certs, err := parseCertificatePEM([]byte(pemData))
-	if err != nil {
-		return "", nil, err
+// domainValidations converts the DigiCert order domains to their domain control validation state,
+// the order random value is used as token for domains without a dedicated one
+func domainValidations(method string, randomValue string, domains []orderDomain) []domain.DomainValidation {
+	var validations []domain.DomainValidation
+	for _, d := range domains {
+		validation := domain.DomainValidation{
+			Domain: d.Name,
+			Method: method,
+			Status: d.DcvStatus,
+			Token:  randomValue,
+		}
+		if d.DcvToken != nil {
+			if d.DcvToken.Token != "" {
+				validation.Token = d.DcvToken.Token
+			}
+			if validation.Status == "" {
+				validation.Status = d.DcvToken.Status
+			}
+		}
+		if validation.Status == "" {
+			validation.Status = "pending"
+		}
+		validations = append(validations, validation)
 	}
+	return validations
+}
 
-	cert := base64.StdEncoding.EncodeToString(certs[0].Raw)
-
-	var chain []string
-	if len(certs) > 1 {
-		for i := 1; i < len(certs); i++ {
-			chain = append(chain, base64.StdEncoding.EncodeToString(certs[i].Raw))
+func pendingDomainValidationMessage(validations []domain.DomainValidation) string {
+	var pending []string
+	for _, validation := range validations {
+		if validation.Status != "complete" && validation.Status != "validated" {
+			pending = append(pending, validation.Domain)
 		}
 	}
-
-	return cert, chain, nil
+	if len(pending) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("waiting for domain control validation of: %s", strings.Join(pending, ", "))
 }
 
 func parseCertificatePEM(certBytes []byte) ([]*x509.Certificate, error) {
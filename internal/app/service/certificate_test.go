@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
@@ -44,6 +45,113 @@ func TestRequestCertificate(t *testing.T) {
 		testCertificateRequest(t, http.StatusBadRequest, false)
 	})
 
+	t.Run("requestCertificateAutoRenew", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+			AutoRenew:      true,
+		}, &domain.ProductDetails{NameID: "ssl_private_id", AllowAutoRenew: true}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, 1, body.AutoRenew)
+		})
+	})
+
+	t.Run("requestCertificateNoAutoRenew", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, 0, body.AutoRenew)
+		})
+	})
+
+	t.Run("requestCertificateEvCodeSign", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID:       productOrganizationId,
+			HashAlgorithm:        productHashAlgorithm,
+			CsProvisioningMethod: domain.CsProvisioningMethodExistingToken,
+			EvApprover: &domain.Contact{
+				FirstName: "Jane",
+				LastName:  "Doe",
+				Email:     "jane.doe@example.com",
+				JobTitle:  "CISO",
+			},
+		}, &domain.ProductDetails{NameID: "code_signing_ev", ValidationType: domain.ValidationTypeEV}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, domain.CsProvisioningMethodExistingToken, body.Certificate.CsProvisioningMethod)
+			require.Equal(t, []digicertContact{{
+				FirstName:   "Jane",
+				LastName:    "Doe",
+				Email:       "jane.doe@example.com",
+				JobTitle:    "CISO",
+				ContactType: "ev_approver",
+			}}, body.Organization.Contacts)
+		})
+	})
+
+	t.Run("requestCertificateDcvMethod", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+			DcvMethod:      domain.DcvMethodDnsTxtToken,
+		}, &domain.ProductDetails{NameID: "ssl_dv_geotrust"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, domain.DcvMethodDnsTxtToken, body.DcvMethod)
+		})
+	})
+
+	t.Run("requestCertificateDefaultServerPlatform", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, -1, body.Certificate.ServerPlatform.ID)
+		})
+	})
+
+	t.Run("requestCertificateServerPlatform", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID:   productOrganizationId,
+			HashAlgorithm:    productHashAlgorithm,
+			ServerPlatformID: 14,
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, 14, body.Certificate.ServerPlatform.ID)
+		})
+	})
+
+	t.Run("requestCertificateContainer", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+			ContainerID:    5,
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, &digicertContainer{ID: 5}, body.Container)
+		})
+	})
+
+	t.Run("requestCertificateAnnotations", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID:   productOrganizationId,
+			HashAlgorithm:    productHashAlgorithm,
+			Comments:         "for the web team",
+			AdditionalEmails: []string{"team@example.com"},
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Equal(t, "for the web team", body.Comments)
+			require.Equal(t, []string{"team@example.com"}, body.AdditionalEmails)
+		})
+	})
+
+	t.Run("requestCertificateOrderNote", func(t *testing.T) {
+		testCertificateOrderNote(t)
+	})
+
+	t.Run("requestCertificateCustomFields", func(t *testing.T) {
+		testCertificateRequestBody(t, domain.Product{
+			OrganizationID: productOrganizationId,
+			HashAlgorithm:  productHashAlgorithm,
+			CustomFields:   []domain.CustomFieldValue{{ID: 10, Value: "42"}},
+		}, &domain.ProductDetails{NameID: "ssl_private_id"}, func(t *testing.T, body *newCertificateRequestBody) {
+			require.Contains(t, body.CustomFields, customField{MetadataID: 10, Value: "42"})
+		})
+	})
+
 	t.Run("successCheckCertificate", func(t *testing.T) {
 		testCheckCertificateData(t, http.StatusOK)
 	})
@@ -60,6 +168,18 @@ func TestRequestCertificate(t *testing.T) {
 		testCheckOrderData(t, http.StatusBadRequest)
 	})
 
+	t.Run("pendingDcvCheckOrder", func(t *testing.T) {
+		testCheckOrderPendingDcv(t)
+	})
+
+	t.Run("triggeredDcvCheckOrder", func(t *testing.T) {
+		testCheckOrderTriggerDcv(t, "pending")
+	})
+
+	t.Run("triggeredDcvCheckOrderIssued", func(t *testing.T) {
+		testCheckOrderTriggerDcv(t, "issued")
+	})
+
 	t.Run("completeRetrieveCertificates", func(t *testing.T) {
 		testRetrieveCertificateData(t, http.StatusOK, certBatchSize, true, true)
 	})
@@ -75,6 +195,14 @@ func TestRequestCertificate(t *testing.T) {
 	t.Run("errorRetrieveCertificates", func(t *testing.T) {
 		testRetrieveCertificateData(t, http.StatusBadRequest, certBatchSize, true, false)
 	})
+
+	t.Run("concurrentRetrieveCertificates", func(t *testing.T) {
+		testRetrieveCertificateData(t, http.StatusOK, certBatchSize, false, true, WithImportConcurrency(4))
+	})
+
+	t.Run("failedDownloadStopsImport", func(t *testing.T) {
+		testDownloadImportCertificatesFailure(t)
+	})
 }
 
 func testCertificateRequest(t *testing.T, httpStatus int, orderDetails bool) {
@@ -137,7 +265,7 @@ func testCertificateRequest(t *testing.T, httpStatus int, orderDetails bool) {
 	)
 	certService := NewCertificateService()
 
-	details, order, _ := certService.RequestCertificate(connection, pkcs10Request, domain.Product{
+	details, order, _ := certService.RequestCertificate(context.Background(), connection, pkcs10Request, domain.Product{
 		OrganizationID: 1,
 		HashAlgorithm:  "sha256",
 		NameID:         "ssl_private_id",
@@ -159,6 +287,176 @@ func testCertificateRequest(t *testing.T, httpStatus int, orderDetails bool) {
 	}
 }
 
+func testCertificateRequestBody(t *testing.T, product domain.Product, productDetails *domain.ProductDetails, validate func(t *testing.T, body *newCertificateRequestBody)) {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	called := false
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderCertificateUri, productDetails.NameID),
+		func(req *http.Request) (*http.Response, error) {
+			called = true
+			data, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+
+			reqBody := &newCertificateRequestBody{}
+			err = json.Unmarshal(data, reqBody)
+			assert.NoError(t, err)
+			validate(t, reqBody)
+
+			return httpmock.NewJsonResponse(http.StatusOK, &digiCertRequestCertificateResponse{
+				ID: 1234,
+			})
+		},
+	)
+
+	_, order, err := NewCertificateService().RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+	require.NoError(t, err)
+	require.True(t, called)
+	require.Equal(t, "1234", order.ID)
+}
+
+func testCertificateOrderNote(t *testing.T) {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderCertificateUri, "ssl_private_id"),
+		httpmock.NewJsonResponderOrPanic(http.StatusOK, &digiCertRequestCertificateResponse{
+			ID: 1234,
+		}),
+	)
+
+	var note orderNoteBody
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderNoteUri, 1234),
+		func(req *http.Request) (*http.Response, error) {
+			data, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+			assert.NoError(t, json.Unmarshal(data, &note))
+			return httpmock.NewJsonResponse(http.StatusCreated, map[string]int{"id": 1})
+		},
+	)
+
+	product := domain.Product{
+		OrganizationID: productOrganizationId,
+		HashAlgorithm:  productHashAlgorithm,
+		NoteTemplate:   "{{.CommonName}} requested through {{.ProductOptionName}}",
+	}
+	_, order, err := NewCertificateService().RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, &domain.ProductDetails{NameID: "ssl_private_id"})
+	require.NoError(t, err)
+	require.Equal(t, "1234", order.ID)
+	require.Equal(t, fmt.Sprintf("digicert-test.com requested through %s", productOptionName), note.Text)
+}
+
+// TestCheckCertificateStates ...
+func TestCheckCertificateStates(t *testing.T) {
+	tests := []struct {
+		name           string
+		httpStatus     int
+		body           string
+		expectedStatus domain.CertificateStatus
+		expectedError  string
+	}{
+		{
+			name:           "issued",
+			httpStatus:     http.StatusOK,
+			body:           ee_cert + "\n" + intermediate_cert + "\n" + root_cert,
+			expectedStatus: domain.CertificateStatusIssued,
+		},
+		{
+			name:           "emptyBody",
+			httpStatus:     http.StatusOK,
+			body:           "",
+			expectedStatus: domain.CertificateStatusRequested,
+		},
+		{
+			name:           "whitespaceBody",
+			httpStatus:     http.StatusOK,
+			body:           "\r\n",
+			expectedStatus: domain.CertificateStatusRequested,
+		},
+		{
+			name:           "notFound",
+			httpStatus:     http.StatusNotFound,
+			body:           `{"errors":[{"code":"not_found","message":"Certificate not found."}]}`,
+			expectedStatus: domain.CertificateStatusRequested,
+		},
+		{
+			name:           "pending",
+			httpStatus:     http.StatusBadRequest,
+			body:           `{"errors":[{"code":"cert_unavailable_processing","message":"Unable to download certificate, the certificate has not yet been issued."}]}`,
+			expectedStatus: domain.CertificateStatusRequested,
+		},
+		{
+			name:           "revoked",
+			httpStatus:     http.StatusBadRequest,
+			body:           `{"errors":[{"code":"cert_unavailable_revoked","message":"Unable to download certificate, the certificate has been revoked."}]}`,
+			expectedStatus: domain.CertificateStatusFailed,
+			expectedError:  "certificate has been revoked",
+		},
+		{
+			name:           "serverError",
+			httpStatus:     http.StatusInternalServerError,
+			body:           `{"errors":[{"code":"internal_error","message":"Internal error."}]}`,
+			expectedStatus: domain.CertificateStatusFailed,
+			expectedError:  `failed to download certificate from DigiCert CA server: {"errors":[{"code":"internal_error","message":"Internal error."}]}`,
+		},
+		{
+			name:           "malformedBody",
+			httpStatus:     http.StatusOK,
+			body:           "not a certificate",
+			expectedStatus: domain.CertificateStatusFailed,
+			expectedError:  "failed to parse certificate downloaded from DigiCert CA server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := buildConnection()
+
+			// override the resty constructor to intercept HTTPS traffic
+			savedRestCtor := NewRestClient
+			defer func() { NewRestClient = savedRestCtor }()
+			NewRestClient = func() *resty.Client {
+				client := resty.New()
+				httpmock.ActivateNonDefault(client.GetClient())
+				return client
+			}
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(downloadCertificateUri, "CertID", domain.DownloadFormatPemAll),
+				httpmock.NewStringResponder(tt.httpStatus, tt.body),
+			)
+
+			details, err := NewCertificateService().CheckCertificate(context.Background(), connection, "CertID")
+			require.NoError(t, err)
+			require.Equal(t, "CertID", details.ID)
+			require.Equal(t, tt.expectedStatus, details.Status)
+			if tt.expectedError == "" {
+				require.Empty(t, details.ErrorMessage)
+			} else {
+				require.Contains(t, details.ErrorMessage, tt.expectedError)
+			}
+		})
+	}
+}
+
 func testCheckCertificateData(t *testing.T, httpStatus int) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -176,7 +474,7 @@ func testCheckCertificateData(t *testing.T, httpStatus int) {
 	}
 	defer httpmock.DeactivateAndReset()
 
-	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, certID),
+	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, certID, domain.DownloadFormatPemAll),
 		func(req *http.Request) (*http.Response, error) {
 			if httpStatus == http.StatusOK {
 				return httpmock.NewStringResponse(http.StatusOK, ee_cert+"\n"+intermediate_cert+"\n"+root_cert), nil
@@ -186,11 +484,13 @@ func testCheckCertificateData(t *testing.T, httpStatus int) {
 	)
 	certificate := NewCertificateService()
 
-	details, err := certificate.CheckCertificate(connection, certID)
+	details, err := certificate.CheckCertificate(context.Background(), connection, certID)
+	require.NoError(t, err)
 	if httpStatus == http.StatusOK {
 		validateIssuanceCertificateDetails(t, details, certID)
 	} else {
-		require.NotEmpty(t, err.Error())
+		require.Equal(t, domain.CertificateStatusFailed, details.Status)
+		require.NotEmpty(t, details.ErrorMessage)
 	}
 }
 
@@ -229,7 +529,7 @@ func testCheckOrderData(t *testing.T, httpStatus int) {
 	)
 	certificate := NewCertificateService()
 
-	details, err := certificate.CheckOrder(connection, strconv.Itoa(orderID))
+	details, err := certificate.CheckOrder(context.Background(), connection, strconv.Itoa(orderID))
 	if httpStatus == http.StatusOK {
 		require.Equal(t, details.ID, strconv.Itoa(orderID))
 		require.Equal(t, details.CertificateID, strconv.Itoa(certID))
@@ -240,7 +540,143 @@ func testCheckOrderData(t *testing.T, httpStatus int) {
 	}
 }
 
-func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, completed bool, includeExpired bool) {
+func testCheckOrderPendingDcv(t *testing.T) {
+	orderID := 1234
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf(orderCertificateUri, strconv.Itoa(orderID)),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, digiCertOrderDetails{
+				ID:             orderID,
+				Status:         "pending",
+				DcvMethod:      domain.DcvMethodDnsTxtToken,
+				DcvRandomValue: "random-value",
+				Domains: []orderDomain{
+					{
+						ID:        1,
+						Name:      "digicert-test.com",
+						DcvStatus: "complete",
+					},
+					{
+						ID:   2,
+						Name: "www.digicert-test.com",
+						DcvToken: &dcvToken{
+							Token:  "domain-token",
+							Status: "pending",
+						},
+					},
+				},
+			})
+		},
+	)
+
+	details, err := NewCertificateService().CheckOrder(context.Background(), connection, strconv.Itoa(orderID))
+	require.NoError(t, err)
+	require.Equal(t, domain.OrderStatusProcessing, details.Status)
+	require.Equal(t, []domain.DomainValidation{
+		{
+			Domain: "digicert-test.com",
+			Method: domain.DcvMethodDnsTxtToken,
+			Status: "complete",
+			Token:  "random-value",
+		},
+		{
+			Domain: "www.digicert-test.com",
+			Method: domain.DcvMethodDnsTxtToken,
+			Status: "pending",
+			Token:  "domain-token",
+		},
+	}, details.DomainValidations)
+	require.Equal(t, "waiting for domain control validation of: www.digicert-test.com", details.ErrorMessage)
+}
+
+func testCheckOrderTriggerDcv(t *testing.T, orderStatus string) {
+	orderID := 1234
+	certID := 5678
+	connection := buildConnection()
+	connection.Configuration.CheckDcv = true
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf(orderCertificateUri, strconv.Itoa(orderID)),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, digiCertOrderDetails{
+				ID:        orderID,
+				Status:    "pending",
+				DcvMethod: domain.DcvMethodDnsTxtToken,
+				Domains: []orderDomain{
+					{
+						ID:        1,
+						Name:      "digicert-test.com",
+						DcvStatus: "pending",
+					},
+				},
+			})
+		},
+	)
+
+	checks := 0
+	httpmock.RegisterResponder("PUT", fmt.Sprintf(checkDcvUri, strconv.Itoa(orderID)),
+		func(req *http.Request) (*http.Response, error) {
+			checks++
+			response := digicertCheckDcvResponse{
+				OrderStatus: orderStatus,
+				DcvStatus:   "pending",
+			}
+			if orderStatus == "issued" {
+				response.DcvStatus = "complete"
+				response.CertificateID = certID
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, response)
+		},
+	)
+
+	certificate := NewCertificateService()
+	details, err := certificate.CheckOrder(context.Background(), connection, strconv.Itoa(orderID))
+	require.NoError(t, err)
+	require.Equal(t, 1, checks)
+	require.NotNil(t, details.DcvCheck)
+	require.NotEmpty(t, details.DcvCheck.CheckedAt)
+	require.Empty(t, details.DcvCheck.ErrorMessage)
+
+	if orderStatus == "issued" {
+		require.Equal(t, domain.OrderStatusCompleted, details.Status)
+		require.Equal(t, strconv.Itoa(certID), details.CertificateID)
+		require.Equal(t, "complete", details.DcvCheck.Status)
+		require.Empty(t, details.ErrorMessage)
+		return
+	}
+
+	require.Equal(t, domain.OrderStatusProcessing, details.Status)
+	require.Equal(t, "pending", details.DcvCheck.Status)
+	require.Equal(t, "waiting for domain control validation of: digicert-test.com", details.ErrorMessage)
+
+	// a second check within the throttle interval reports the previous outcome without calling DigiCert
+	again, err := certificate.CheckOrder(context.Background(), connection, strconv.Itoa(orderID))
+	require.NoError(t, err)
+	require.Equal(t, 1, checks)
+	require.Equal(t, details.DcvCheck, again.DcvCheck)
+}
+
+func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, completed bool, includeExpired bool, opts ...CertificateOption) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -304,7 +740,7 @@ func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, compl
 		},
 	)
 
-	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, strconv.Itoa(certID1)),
+	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, strconv.Itoa(certID1), domain.DownloadFormatPemAll),
 		func(req *http.Request) (*http.Response, error) {
 			if httpStatus == http.StatusOK {
 				return httpmock.NewStringResponse(http.StatusOK, ee_cert+"\n"+intermediate_cert+"\n"+root_cert), nil
@@ -313,7 +749,7 @@ func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, compl
 		},
 	)
 
-	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, strconv.Itoa(certID2)),
+	httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, strconv.Itoa(certID2), domain.DownloadFormatPemAll),
 		func(req *http.Request) (*http.Response, error) {
 			if httpStatus == http.StatusOK {
 				return httpmock.NewStringResponse(http.StatusOK, ee_cert+"\n"+intermediate_cert+"\n"+root_cert), nil
@@ -322,7 +758,7 @@ func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, compl
 		},
 	)
 
-	certificate := NewCertificateService()
+	certificate := NewCertificateService(opts...)
 
 	option := domain.ImportOption{
 		Name:        "Private SSL Certificates",
@@ -337,7 +773,7 @@ func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, compl
 	}
 
 	startCursor := strconv.Itoa(cursor)
-	details, err := certificate.RetrieveCertificates(connection, option, configuration, startCursor, 2)
+	details, err := certificate.RetrieveCertificates(context.Background(), connection, option, configuration, startCursor, 2)
 	if httpStatus == http.StatusOK {
 		if completed {
 			require.Equal(t, details.ImportStatus, domain.ImportStatusCompleted)
@@ -361,6 +797,36 @@ func testRetrieveCertificateData(t *testing.T, httpStatus int, cursor int, compl
 	}
 }
 
+func testDownloadImportCertificatesFailure(t *testing.T) {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	var orders []digiCertOrderDetails
+	for _, id := range []int{1, 2, 3} {
+		orders = append(orders, digiCertOrderDetails{ID: id, Certificate: &orderCertificate{ID: id}})
+		status := http.StatusOK
+		if id == 1 {
+			status = http.StatusForbidden
+		}
+		httpmock.RegisterResponder("GET", fmt.Sprintf(downloadCertificateUri, strconv.Itoa(id), domain.DownloadFormatPemAll),
+			httpmock.NewStringResponder(status, ee_cert+"\n"+intermediate_cert+"\n"+root_cert))
+	}
+
+	certificates, err := NewCertificateService(WithImportConcurrency(1)).downloadImportCertificates(context.Background(), connection, orders)
+	require.Error(t, err)
+	require.Nil(t, certificates)
+	require.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
 func validateIssuanceCertificateDetails(t *testing.T, details *domain.CertificateDetails, certID string) {
 	validateCertificateDetails(t, details.Certificate, details.Chain, details.ID, certID)
 	require.Equal(t, details.Status, domain.CertificateStatusIssued)
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	downloadCertificateUri = "/certificate/%s/download/format/%s"
+)
+
+// downloadFormats lists the DigiCert download formats the connector is able to parse
+var downloadFormats = []string{
+	domain.DownloadFormatPemAll,
+	domain.DownloadFormatPemNoRoot,
+	domain.DownloadFormatP7b,
+}
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     pkcs7RawCertificates   `asn1:"optional,tag:0"`
+	CRLs             []pkix.CertificateList `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+type pkcs7RawCertificates struct {
+	Raw asn1.RawContent
+}
+
+// downloadCertificatePath returns the download URI of the certificate in the format configured on the connection
+func downloadCertificatePath(configuration domain.Configuration, id string) (string, error) {
+	format := configuration.DownloadFormat
+	if format == "" {
+		format = domain.DownloadFormatPemAll
+	}
+	if !contains(downloadFormats, format) {
+		return "", fmt.Errorf("unsupported certificate download format: %s", format)
+	}
+	return fmt.Sprintf(downloadCertificateUri, id, format), nil
+}
+
+// parseCertificateData returns the leaf certificate and its chain ordered leaf to root, applying the chain policy of the connection
+func parseCertificateData(data string, configuration domain.Configuration) (string, []string, error) {
+	certs, err := parseCertificateChain(data)
+	if err != nil {
+		return "", nil, err
+	}
+	cert, chain := encodeCertificateChain(certs, configuration)
+	return cert, chain, nil
+}
+
+// parseCertificateChain parses the downloaded certificates and orders them leaf to root
+func parseCertificateChain(data string) ([]*x509.Certificate, error) {
+	certs, err := parseCertificateBundle([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in downloaded data")
+	}
+	return orderChain(certs), nil
+}
+
+// encodeCertificateChain encodes the leaf certificate and its chain, leaving out the root when the connection asks so
+func encodeCertificateChain(certs []*x509.Certificate, configuration domain.Configuration) (string, []string) {
+	cert := base64.StdEncoding.EncodeToString(certs[0].Raw)
+
+	var chain []string
+	for _, c := range certs[1:] {
+		if configuration.ExcludeRootFromChain && isSelfIssued(c) {
+			continue
+		}
+		chain = append(chain, base64.StdEncoding.EncodeToString(c.Raw))
+	}
+	return cert, chain
+}
+
+// verifiedCertificateData parses the downloaded certificates and verifies they build to a trust anchor
+func (cs *Certificate) verifiedCertificateData(data string, configuration domain.Configuration) (string, []string, chainResult, error) {
+	certs, err := parseCertificateChain(data)
+	if err != nil {
+		return "", nil, chainResult{}, err
+	}
+	result := cs.chainVerifier.verify(certs)
+	if result.err != nil {
+		zap.L().Warn("certificate chain does not build to a trust anchor", zap.String("subject", certs[0].Subject.String()), zap.Error(result.err))
+	}
+	cert, chain := encodeCertificateChain(result.certificates, configuration)
+	return cert, chain, result, nil
+}
+
+// errorMessage returns the reason the chain could not be verified, if any
+func (r chainResult) errorMessage() string {
+	if r.err == nil {
+		return ""
+	}
+	return r.err.Error()
+}
+
+// parseCertificateBundle parses PEM encoded certificates as well as PEM or DER encoded PKCS#7 bundles
+func parseCertificateBundle(data []byte) ([]*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return parsePKCS7(data)
+	}
+	if block.Type == "PKCS7" {
+		return parsePKCS7(block.Bytes)
+	}
+	return parseCertificatePEM(data)
+}
+
+// parsePKCS7 extracts the certificates of a degenerate PKCS#7 signed data structure
+func parsePKCS7(der []byte) ([]*x509.Certificate, error) {
+	contentInfo := pkcs7ContentInfo{}
+	if _, err := asn1.Unmarshal(der, &contentInfo); err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#7 content info: %s", err)
+	}
+	if !contentInfo.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported PKCS#7 content type: %s", contentInfo.ContentType)
+	}
+
+	signedData := pkcs7SignedData{}
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &signedData); err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#7 signed data: %s", err)
+	}
+
+	if len(signedData.Certificates.Raw) == 0 {
+		return nil, nil
+	}
+	var certificates asn1.RawValue
+	if _, err := asn1.Unmarshal(signedData.Certificates.Raw, &certificates); err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#7 certificates: %s", err)
+	}
+
+	certs, err := x509.ParseCertificates(certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PKCS#7 certificates: %s", err)
+	}
+	return certs, nil
+}
+
+// orderChain orders certificates leaf to root following issuer and subject linkage, keeping unlinked certificates at the end
+func orderChain(certs []*x509.Certificate) []*x509.Certificate {
+	if len(certs) < 2 {
+		return certs
+	}
+
+	// the leaf is the certificate that did not issue any other certificate of the bundle
+	leaf := -1
+	for i, c := range certs {
+		issuer := false
+		for j, other := range certs {
+			if i != j && issuedBy(other, c) {
+				issuer = true
+				break
+			}
+		}
+		if !issuer {
+			leaf = i
+			break
+		}
+	}
+	if leaf < 0 {
+		return certs
+	}
+
+	used := make([]bool, len(certs))
+	used[leaf] = true
+	ordered := []*x509.Certificate{certs[leaf]}
+	for current := certs[leaf]; !isSelfIssued(current); {
+		next := -1
+		for i, c := range certs {
+			if !used[i] && issuedBy(current, c) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		used[next] = true
+		current = certs[next]
+		ordered = append(ordered, current)
+	}
+
+	for i, c := range certs {
+		if !used[i] {
+			ordered = append(ordered, c)
+		}
+	}
+	return ordered
+}
+
+// issuedBy reports whether the subject and key identifier of the issuer match the issuer of the certificate
+func issuedBy(cert *x509.Certificate, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+func isSelfIssued(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+// TestParseCertificateData ...
+func TestParseCertificateData(t *testing.T) {
+	t.Run("orderedChain", func(t *testing.T) {
+		cert, chain, err := parseCertificateData(root_cert+"\n"+ee_cert+"\n"+intermediate_cert, domain.Configuration{})
+		require.NoError(t, err)
+		validateCertificateDetails(t, cert, chain, "", "")
+	})
+
+	t.Run("excludeRoot", func(t *testing.T) {
+		cert, chain, err := parseCertificateData(ee_cert+"\n"+root_cert+"\n"+intermediate_cert, domain.Configuration{ExcludeRootFromChain: true})
+		require.NoError(t, err)
+		require.Equal(t, encodedCertificate(ee_cert), cert)
+		require.Equal(t, []string{encodedCertificate(intermediate_cert)}, chain)
+	})
+
+	t.Run("pkcs7Pem", func(t *testing.T) {
+		p7b := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: buildPKCS7(t, intermediate_cert, ee_cert, root_cert)})
+		cert, chain, err := parseCertificateData(string(p7b), domain.Configuration{})
+		require.NoError(t, err)
+		validateCertificateDetails(t, cert, chain, "", "")
+	})
+
+	t.Run("pkcs7Der", func(t *testing.T) {
+		cert, chain, err := parseCertificateData(string(buildPKCS7(t, ee_cert, intermediate_cert)), domain.Configuration{})
+		require.NoError(t, err)
+		require.Equal(t, encodedCertificate(ee_cert), cert)
+		require.Equal(t, []string{encodedCertificate(intermediate_cert)}, chain)
+	})
+
+	t.Run("noCertificate", func(t *testing.T) {
+		_, _, err := parseCertificateData("", domain.Configuration{})
+		require.Error(t, err)
+	})
+}
+
+// TestDownloadCertificatePath ...
+func TestDownloadCertificatePath(t *testing.T) {
+	uri, err := downloadCertificatePath(domain.Configuration{}, "1")
+	require.NoError(t, err)
+	require.Equal(t, "/certificate/1/download/format/pem_all", uri)
+
+	uri, err = downloadCertificatePath(domain.Configuration{DownloadFormat: domain.DownloadFormatPemNoRoot}, "1")
+	require.NoError(t, err)
+	require.Equal(t, "/certificate/1/download/format/pem_noroot", uri)
+
+	_, err = downloadCertificatePath(domain.Configuration{DownloadFormat: "pfx"}, "1")
+	require.Error(t, err)
+}
+
+// TestCheckCertificateDownloadFormat ...
+func TestCheckCertificateDownloadFormat(t *testing.T) {
+	connection := buildConnection()
+	connection.Configuration.DownloadFormat = domain.DownloadFormatP7b
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	p7b := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: buildPKCS7(t, root_cert, intermediate_cert, ee_cert)})
+	httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(downloadCertificateUri, "CertID", domain.DownloadFormatP7b),
+		httpmock.NewStringResponder(http.StatusOK, string(p7b)),
+	)
+
+	details, err := NewCertificateService().CheckCertificate(context.Background(), connection, "CertID")
+	require.NoError(t, err)
+	validateIssuanceCertificateDetails(t, details, "CertID")
+}
+
+func encodedCertificate(pemCert string) string {
+	block, _ := pem.Decode([]byte(pemCert))
+	return base64.StdEncoding.EncodeToString(block.Bytes)
+}
+
+func buildPKCS7(t *testing.T, pemCerts ...string) []byte {
+	var certs []byte
+	for _, pemCert := range pemCerts {
+		block, _ := pem.Decode([]byte(pemCert))
+		certs = append(certs, block.Bytes...)
+	}
+
+	emptySet := asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true}
+	contentInfo, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+	}{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})
+	require.NoError(t, err)
+
+	signedData, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms asn1.RawValue
+		ContentInfo      asn1.RawValue
+		Certificates     asn1.RawValue
+		SignerInfos      asn1.RawValue
+	}{
+		Version:          1,
+		DigestAlgorithms: emptySet,
+		ContentInfo:      asn1.RawValue{FullBytes: contentInfo},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:      emptySet,
+	})
+	require.NoError(t, err)
+
+	der, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+	require.NoError(t, err)
+	return der
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultIssuerFetchTimeout bounds the time spent downloading a missing issuer certificate
+	DefaultIssuerFetchTimeout = 10 * time.Second
+
+	maxIssuerFetches     = 5
+	maxIssuerFetchedSize = 1 << 20
+)
+
+// IssuerFetcher retrieves issuer certificates published at an AIA caIssuers URL
+type IssuerFetcher interface {
+	FetchIssuer(url string) ([]*x509.Certificate, error)
+}
+
+// HTTPIssuerFetcher retrieves issuer certificates over HTTP, from the allowed URLs only
+type HTTPIssuerFetcher struct {
+	client      *http.Client
+	allowedURLs []string
+}
+
+// NewHTTPIssuerFetcher will return an issuer fetcher giving up after the timeout, and downloading only the URLs
+// under one of the allowed base URLs, redirects included, so that certificates cannot make the connector send
+// requests to arbitrary hosts
+func NewHTTPIssuerFetcher(timeout time.Duration, allowedURLs []string) *HTTPIssuerFetcher {
+	return &HTTPIssuerFetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if !urlAllowed(req.URL.String(), allowedURLs) {
+					return fmt.Errorf("issuer URL redirected to %s, which is not allowed", req.URL)
+				}
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after 10 redirects")
+				}
+				return nil
+			},
+		},
+		allowedURLs: allowedURLs,
+	}
+}
+
+// FetchIssuer downloads the DER, PEM or PKCS#7 encoded certificates published at the URL
+func (f *HTTPIssuerFetcher) FetchIssuer(url string) ([]*x509.Certificate, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported issuer URL: %s", url)
+	}
+	if !urlAllowed(url, f.allowedURLs) {
+		return nil, fmt.Errorf("issuer URL %s is not allowed", url)
+	}
+
+	resp, err := f.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch issuer from %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxIssuerFetchedSize))
+	if err != nil {
+		return nil, err
+	}
+	if certs, err := x509.ParseCertificates(data); err == nil {
+		return certs, nil
+	}
+	return parseCertificateBundle(data)
+}
+
+// LoadTrustBundle reads the PEM encoded trust anchors chains have to build to
+func LoadTrustBundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in trust bundle %s", path)
+	}
+	return roots, nil
+}
+
+// ChainVerifier builds the path from a leaf certificate to a trust anchor, completing missing intermediates from AIA
+type ChainVerifier struct {
+	roots   *x509.CertPool
+	fetcher IssuerFetcher
+}
+
+// NewChainVerifier will return a chain verifier trusting the roots, or the system roots when nil, and completing
+// chains with the fetcher, or never when nil
+func NewChainVerifier(roots *x509.CertPool, fetcher IssuerFetcher) *ChainVerifier {
+	return &ChainVerifier{
+		roots:   roots,
+		fetcher: fetcher,
+	}
+}
+
+type chainResult struct {
+	certificates []*x509.Certificate
+	status       domain.ChainStatus
+	err          error
+}
+
+// verify returns the verified path of the leaf to root ordered certificates, or the certificates as given when no
+// path to a trust anchor could be built
+func (v *ChainVerifier) verify(certs []*x509.Certificate) chainResult {
+	leaf := certs[0]
+	known := append([]*x509.Certificate{}, certs[1:]...)
+	fetched := false
+
+	var err error
+	for fetches := 0; ; fetches++ {
+		intermediates := x509.NewCertPool()
+		for _, c := range known {
+			// roots returned by the CA or published at AIA URLs are not trust anchors
+			if !isSelfIssued(c) {
+				intermediates.AddCert(c)
+			}
+		}
+
+		var chains [][]*x509.Certificate
+		chains, err = leaf.Verify(x509.VerifyOptions{
+			Roots:         v.roots,
+			Intermediates: intermediates,
+			CurrentTime:   verificationTime(leaf),
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err == nil {
+			status := domain.ChainStatusVerified
+			if fetched {
+				status = domain.ChainStatusCompleted
+			}
+			return chainResult{
+				certificates: verifiedPath(chains[0], certs),
+				status:       status,
+			}
+		}
+
+		if v.fetcher == nil || fetches == maxIssuerFetches {
+			break
+		}
+		top := topOfChain(leaf, known)
+		if isSelfIssued(top) || len(top.IssuingCertificateURL) == 0 {
+			break
+		}
+		issuers, fetchErr := v.fetchIssuer(top.IssuingCertificateURL)
+		if fetchErr != nil {
+			err = fetchErr
+			break
+		}
+		known = append(known, issuers...)
+		fetched = true
+	}
+
+	return chainResult{
+		certificates: certs,
+		status:       domain.ChainStatusUnresolved,
+		err:          err,
+	}
+}
+
+func (v *ChainVerifier) fetchIssuer(urls []string) ([]*x509.Certificate, error) {
+	var err error
+	for _, url := range urls {
+		var issuers []*x509.Certificate
+		issuers, err = v.fetcher.FetchIssuer(url)
+		if err == nil && len(issuers) > 0 {
+			return issuers, nil
+		}
+		zap.L().Warn("failed to fetch issuer certificate", zap.String("url", url), zap.Error(err))
+	}
+	if err == nil {
+		err = fmt.Errorf("no issuer certificate found at %s", strings.Join(urls, ", "))
+	}
+	return nil, err
+}
+
+// verificationTime verifies expired certificates, which may still be imported, at the time they expired
+func verificationTime(leaf *x509.Certificate) time.Time {
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return leaf.NotAfter
+	}
+	return now
+}
+
+// topOfChain follows issuer linkage from the leaf and returns the last certificate whose issuer is known
+func topOfChain(leaf *x509.Certificate, known []*x509.Certificate) *x509.Certificate {
+	current := leaf
+	for i := 0; i <= len(known) && !isSelfIssued(current); i++ {
+		var issuer *x509.Certificate
+		for _, c := range known {
+			if c != current && issuedBy(current, c) {
+				issuer = c
+				break
+			}
+		}
+		if issuer == nil {
+			break
+		}
+		current = issuer
+	}
+	return current
+}
+
+// verifiedPath keeps the trust anchor in the path only when the CA returned a root itself
+func verifiedPath(path []*x509.Certificate, returned []*x509.Certificate) []*x509.Certificate {
+	for _, c := range returned[1:] {
+		if isSelfIssued(c) {
+			return path
+		}
+	}
+	if len(path) > 1 && isSelfIssued(path[len(path)-1]) {
+		return path[:len(path)-1]
+	}
+	return path
+}
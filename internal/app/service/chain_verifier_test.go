@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+type testPKI struct {
+	root         *x509.Certificate
+	intermediate *x509.Certificate
+	leaf         *x509.Certificate
+}
+
+// TestChainVerifier ...
+func TestChainVerifier(t *testing.T) {
+	var issuerRequests int
+	var pki *testPKI
+	aia := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuerRequests++
+		if r.URL.Path != "/intermediate.crt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(pki.intermediate.Raw)
+	}))
+	defer aia.Close()
+	pki = newTestPKI(t, aia.URL+"/intermediate.crt")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(pki.root)
+	fetcher := NewHTTPIssuerFetcher(DefaultIssuerFetchTimeout, []string{aia.URL})
+
+	t.Run("verified", func(t *testing.T) {
+		issuerRequests = 0
+		result := NewChainVerifier(roots, fetcher).verify([]*x509.Certificate{pki.leaf, pki.intermediate, pki.root})
+		require.NoError(t, result.err)
+		require.Equal(t, domain.ChainStatusVerified, result.status)
+		require.Equal(t, []*x509.Certificate{pki.leaf, pki.intermediate, pki.root}, result.certificates)
+		require.Zero(t, issuerRequests)
+	})
+
+	t.Run("completedFromAia", func(t *testing.T) {
+		issuerRequests = 0
+		result := NewChainVerifier(roots, fetcher).verify([]*x509.Certificate{pki.leaf})
+		require.NoError(t, result.err)
+		require.Equal(t, domain.ChainStatusCompleted, result.status)
+		require.Equal(t, []*x509.Certificate{pki.leaf, pki.intermediate}, result.certificates)
+		require.Equal(t, 1, issuerRequests)
+	})
+
+	t.Run("missingIntermediateWithoutFetcher", func(t *testing.T) {
+		result := NewChainVerifier(roots, nil).verify([]*x509.Certificate{pki.leaf})
+		require.Error(t, result.err)
+		require.Equal(t, domain.ChainStatusUnresolved, result.status)
+		require.Equal(t, []*x509.Certificate{pki.leaf}, result.certificates)
+	})
+
+	t.Run("issuerNotPublished", func(t *testing.T) {
+		other := newTestPKI(t, aia.URL+"/missing.crt")
+		result := NewChainVerifier(roots, fetcher).verify([]*x509.Certificate{other.leaf})
+		require.Error(t, result.err)
+		require.Contains(t, result.err.Error(), "404")
+		require.Equal(t, domain.ChainStatusUnresolved, result.status)
+	})
+
+	t.Run("issuerNotAllowed", func(t *testing.T) {
+		issuerRequests = 0
+		result := NewChainVerifier(roots, NewHTTPIssuerFetcher(DefaultIssuerFetchTimeout, []string{"http://cacerts.digicert.com"})).verify([]*x509.Certificate{pki.leaf})
+		require.Error(t, result.err)
+		require.Contains(t, result.err.Error(), "not allowed")
+		require.Equal(t, domain.ChainStatusUnresolved, result.status)
+		require.Zero(t, issuerRequests)
+	})
+
+	t.Run("untrustedRoot", func(t *testing.T) {
+		issuerRequests = 0
+		result := NewChainVerifier(x509.NewCertPool(), fetcher).verify([]*x509.Certificate{pki.leaf, pki.intermediate, pki.root})
+		require.Error(t, result.err)
+		require.Equal(t, domain.ChainStatusUnresolved, result.status)
+		require.Equal(t, []*x509.Certificate{pki.leaf, pki.intermediate, pki.root}, result.certificates)
+		require.Zero(t, issuerRequests)
+	})
+
+	t.Run("checkCertificate", func(t *testing.T) {
+		connection := buildConnection()
+
+		// override the resty constructor to intercept HTTPS traffic
+		savedRestCtor := NewRestClient
+		defer func() { NewRestClient = savedRestCtor }()
+		NewRestClient = func() *resty.Client {
+			client := resty.New()
+			httpmock.ActivateNonDefault(client.GetClient())
+			return client
+		}
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", serverURL+"/certificate/CertID/download/format/pem_all",
+			httpmock.NewStringResponder(http.StatusOK, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pki.leaf.Raw}))),
+		)
+
+		certificate := NewCertificateService(WithChainVerifier(NewChainVerifier(roots, fetcher)))
+		details, err := certificate.CheckCertificate(context.Background(), connection, "CertID")
+		require.NoError(t, err)
+		require.Equal(t, domain.ChainStatusCompleted, details.ChainStatus)
+		require.Empty(t, details.ChainError)
+		require.Equal(t, []string{base64.StdEncoding.EncodeToString(pki.intermediate.Raw)}, details.Chain)
+
+		certificate = NewCertificateService(WithChainVerifier(NewChainVerifier(x509.NewCertPool(), nil)))
+		details, err = certificate.CheckCertificate(context.Background(), connection, "CertID")
+		require.NoError(t, err)
+		require.Equal(t, domain.ChainStatusUnresolved, details.ChainStatus)
+		require.NotEmpty(t, details.ChainError)
+		require.Empty(t, details.Chain)
+	})
+}
+
+// TestHTTPIssuerFetcher ...
+func TestHTTPIssuerFetcher(t *testing.T) {
+	pki := newTestPKI(t, "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".pem") {
+			_, _ = w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pki.intermediate.Raw}))
+			return
+		}
+		_, _ = w.Write(pki.intermediate.Raw)
+	}))
+	defer server.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirecting.Close()
+
+	fetcher := NewHTTPIssuerFetcher(DefaultIssuerFetchTimeout, []string{server.URL})
+	for _, path := range []string{"/issuer.crt", "/issuer.pem"} {
+		certs, err := fetcher.FetchIssuer(server.URL + path)
+		require.NoError(t, err)
+		require.Equal(t, []*x509.Certificate{pki.intermediate}, certs)
+	}
+
+	_, err := fetcher.FetchIssuer("ldap://ldap.example.com/issuer")
+	require.Error(t, err)
+
+	_, err = fetcher.FetchIssuer(redirecting.URL + "/issuer.crt")
+	require.ErrorContains(t, err, "not allowed")
+
+	_, err = NewHTTPIssuerFetcher(DefaultIssuerFetchTimeout, []string{redirecting.URL}).FetchIssuer(redirecting.URL + "/issuer.crt")
+	require.ErrorContains(t, err, "not allowed")
+
+	_, err = NewHTTPIssuerFetcher(DefaultIssuerFetchTimeout, nil).FetchIssuer(server.URL + "/issuer.crt")
+	require.ErrorContains(t, err, "not allowed")
+}
+
+func newTestPKI(t *testing.T, issuerURL string) *testPKI {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root := createTestCertificate(t, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	intermediateTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	intermediate := createTestCertificate(t, intermediateTemplate, root, &intermediateKey.PublicKey, rootKey)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		DNSNames:     []string{"www.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if issuerURL != "" {
+		leafTemplate.IssuingCertificateURL = []string{issuerURL}
+	}
+	leaf := createTestCertificate(t, leafTemplate, intermediate, &leafKey.PublicKey, intermediateKey)
+
+	return &testPKI{
+		root:         root,
+		intermediate: intermediate,
+		leaf:         leaf,
+	}
+}
+
+func createTestCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, pub *ecdsa.PublicKey, priv *ecdsa.PrivateKey) *x509.Certificate {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// DefaultRequestTimeout bounds every request sent to DigiCert, retries excluded
+	DefaultRequestTimeout = 60 * time.Second
+	// DefaultMaxRetries is how many times a failed idempotent request is sent again
+	DefaultMaxRetries = 2
+	// DefaultRetryWaitTime is the wait before the first retry, doubled for each of the following ones
+	DefaultRetryWaitTime = 500 * time.Millisecond
+	// DefaultRetryMaxWaitTime caps the wait between two retries
+	DefaultRetryMaxWaitTime = 5 * time.Second
+)
+
+// RetryPolicy controls how the requests failed with a transport error, a 429 or a 5xx status are sent again.
+// Only GET requests are retried, so that no certificate request or revocation is ever submitted twice
+type RetryPolicy struct {
+	MaxRetries  int           `yaml:"maxRetries" env:"DIGICERT_MAX_RETRIES"`
+	WaitTime    time.Duration `yaml:"waitTime" env:"DIGICERT_RETRY_WAIT_TIME"`
+	MaxWaitTime time.Duration `yaml:"maxWaitTime" env:"DIGICERT_RETRY_MAX_WAIT_TIME"`
+}
+
+// RestClientConfig controls the client sending the requests to DigiCert
+type RestClientConfig struct {
+	// Timeout bounds every request, retries excluded
+	Timeout time.Duration `yaml:"timeout" env:"DIGICERT_REQUEST_TIMEOUT"`
+	Retry   RetryPolicy   `yaml:"retry"`
+	// AllowedURLs are the DigiCert API base URLs the connections may use, any URL is allowed when empty
+	AllowedURLs []string `yaml:"allowedUrls" env:"DIGICERT_ALLOWED_URLS"`
+}
+
+// RestClientFactory returns a resty client constructor, to be set as NewRestClient, applying the configuration
+func RestClientFactory(config RestClientConfig) func() *resty.Client {
+	return func() *resty.Client {
+		client := resty.New().
+			SetTimeout(config.Timeout).
+			SetRetryCount(config.Retry.MaxRetries).
+			SetRetryWaitTime(config.Retry.WaitTime).
+			SetRetryMaxWaitTime(config.Retry.MaxWaitTime).
+			AddRetryCondition(retryCondition)
+		if len(config.AllowedURLs) > 0 {
+			client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+				if !urlAllowed(r.URL, config.AllowedURLs) {
+					return fmt.Errorf("DigiCert URL %q is not allowed", r.URL)
+				}
+				return nil
+			})
+		}
+		return client
+	}
+}
+
+// retryCondition retries the GET requests failed with a transport error or a status DigiCert may recover from
+func retryCondition(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil || resp.Request.Method != http.MethodGet {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode() == http.StatusTooManyRequests || resp.StatusCode() >= http.StatusInternalServerError
+}
+
+// urlAllowed tells whether the request URL is under one of the allowed base URLs, with the same scheme and host
+func urlAllowed(rawURL string, allowed []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, base := range allowed {
+		b, err := url.Parse(base)
+		if err != nil {
+			continue
+		}
+		basePath := strings.TrimSuffix(b.Path, "/")
+		if strings.EqualFold(u.Scheme, b.Scheme) && strings.EqualFold(u.Host, b.Host) &&
+			(u.Path == basePath || strings.HasPrefix(u.Path, basePath+"/")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+func TestURLAllowed(t *testing.T) {
+	allowed := []string{"https://www.digicert.com/services/v2/", "https://digicert-test"}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{url: "https://www.digicert.com/services/v2/order/certificate/12", expected: true},
+		{url: "https://WWW.DIGICERT.COM/services/v2", expected: true},
+		{url: "https://digicert-test/organization", expected: true},
+		{url: "https://www.digicert.com/services/v22/order", expected: false},
+		{url: "http://www.digicert.com/services/v2/order", expected: false},
+		{url: "https://www.digicert.com.attacker/services/v2/order", expected: false},
+		{url: "https://digicert-test:8443/organization", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			require.Equal(t, tt.expected, urlAllowed(tt.url, allowed))
+		})
+	}
+}
+
+func TestRestClientFactory(t *testing.T) {
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+
+	var calls atomic.Int32
+	status := atomic.Int32{}
+	digicert := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer digicert.Close()
+
+	config := RestClientConfig{
+		Timeout:     time.Second,
+		Retry:       RetryPolicy{MaxRetries: 2, WaitTime: time.Millisecond, MaxWaitTime: time.Millisecond},
+		AllowedURLs: []string{digicert.URL},
+	}
+	NewRestClient = RestClientFactory(config)
+	connection := domain.Connection{Configuration: domain.Configuration{ServerURL: digicert.URL}}
+
+	tests := []struct {
+		name          string
+		method        string
+		status        int
+		expectedCalls int32
+	}{
+		{name: "success", method: http.MethodGet, status: http.StatusOK, expectedCalls: 1},
+		{name: "clientError", method: http.MethodGet, status: http.StatusBadRequest, expectedCalls: 1},
+		{name: "retriedServerError", method: http.MethodGet, status: http.StatusServiceUnavailable, expectedCalls: 3},
+		{name: "retriedTooManyRequests", method: http.MethodGet, status: http.StatusTooManyRequests, expectedCalls: 3},
+		{name: "postNotRetried", method: http.MethodPost, status: http.StatusServiceUnavailable, expectedCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			status.Store(int32(tt.status))
+			retries := testutil.ToFloat64(digicertRetries.WithLabelValues("/order/certificate/{id}"))
+
+			_, _ = executeRequest(context.Background(), connection, nil, "/order/certificate/12", tt.method)
+			require.Equal(t, tt.expectedCalls, calls.Load())
+			require.Equal(t, retries+float64(tt.expectedCalls-1), testutil.ToFloat64(digicertRetries.WithLabelValues("/order/certificate/{id}")))
+		})
+	}
+
+	t.Run("notAllowed", func(t *testing.T) {
+		calls.Store(0)
+		other := domain.Connection{Configuration: domain.Configuration{ServerURL: "https://digicert.attacker"}}
+		_, err := executeRequest(context.Background(), other, nil, "/organization", http.MethodGet)
+		require.ErrorContains(t, err, `DigiCert URL "https://digicert.attacker/organization" is not allowed`)
+		require.Zero(t, calls.Load())
+	})
+}
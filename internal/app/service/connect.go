@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
 	"net/http"
 )
@@ -19,7 +20,7 @@ func NewConnectionService() *Connector {
 }
 
 // TestConnection will test connection against a Certificate Authority
-func (cs *Connector) TestConnection(connection domain.Connection) error {
-	_, err := executeRequest(connection, nil, testConnectionUri, http.MethodGet)
+func (cs *Connector) TestConnection(ctx context.Context, connection domain.Connection) error {
+	_, err := executeRequest(ctx, connection, nil, testConnectionUri, http.MethodGet)
 	return err
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -56,7 +57,7 @@ func testConnect(t *testing.T, httpStatus int) {
 	)
 	connector := NewConnectionService()
 
-	err := connector.TestConnection(connection)
+	err := connector.TestConnection(context.Background(), connection)
 	if httpStatus == http.StatusOK {
 		require.NoError(t, err)
 	} else {
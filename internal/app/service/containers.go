@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	getContainersUri    = "/container"
+	getProductLimitsUri = "/product/limits"
+)
+
+type container struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id"`
+	IsActive bool   `json:"is_active"`
+}
+
+type getContainersResponse struct {
+	Containers []container `json:"containers"`
+}
+
+type productLimit struct {
+	NameID    string `json:"name_id"`
+	IsAllowed bool   `json:"is_allowed"`
+}
+
+type containerProductLimits struct {
+	ContainerID   int            `json:"container_id"`
+	ProductLimits []productLimit `json:"product_limits"`
+}
+
+type getProductLimitsResponse struct {
+	Limits []containerProductLimits `json:"limits"`
+}
+
+// containerOptions contains the containers accessible by the API key together with the products each of them allows
+type containerOptions struct {
+	containers []domain.Container
+	products   map[int][]string
+}
+
+// forProduct returns the containers in which the product with the given name ID can be ordered
+func (co containerOptions) forProduct(nameID string) []domain.Container {
+	containers := make([]domain.Container, 0)
+	for _, c := range co.containers {
+		if contains(co.products[c.ID], nameID) {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+// getContainerOptions retrieves the active containers (divisions) and their allowed products. The organizations of
+// each container are taken from the given organizations, listed once for all the containers
+func getContainerOptions(ctx context.Context, connection domain.Connection, organizations []organization) (*containerOptions, error) {
+	resp, err := executeRequest(ctx, connection, nil, getContainersUri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	containersResponse := getContainersResponse{}
+	err = json.Unmarshal(resp.Body(), &containersResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err = executeRequest(ctx, connection, nil, getProductLimitsUri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	limitsResponse := getProductLimitsResponse{}
+	err = json.Unmarshal(resp.Body(), &limitsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &containerOptions{
+		containers: make([]domain.Container, 0),
+		products:   make(map[int][]string),
+	}
+	for _, limits := range limitsResponse.Limits {
+		for _, limit := range limits.ProductLimits {
+			if limit.IsAllowed {
+				options.products[limits.ContainerID] = append(options.products[limits.ContainerID], limit.NameID)
+			}
+		}
+	}
+
+	containerOrganizations := make(map[int][]int)
+	for _, org := range organizations {
+		if org.Container != nil {
+			containerOrganizations[org.Container.ID] = append(containerOrganizations[org.Container.ID], org.ID)
+		}
+	}
+
+	for _, c := range containersResponse.Containers {
+		if !c.IsActive {
+			continue
+		}
+		ids := containerOrganizations[c.ID]
+		if ids == nil {
+			ids = make([]int, 0)
+		}
+		options.containers = append(options.containers, domain.Container{
+			ID:            c.ID,
+			Name:          c.Name,
+			Organizations: ids,
+		})
+	}
+	return options, nil
+}
+
+// validateContainer checks that the chosen container allows the product and holds the chosen organization
+func validateContainer(option domain.ProductOption, product domain.Product) []domain.ProductError {
+	if product.ContainerID == 0 {
+		return nil
+	}
+
+	for _, c := range option.Details.Containers {
+		if c.ID != product.ContainerID {
+			continue
+		}
+		for _, org := range c.Organizations {
+			if org == product.OrganizationID {
+				return nil
+			}
+		}
+		return []domain.ProductError{
+			{
+				AttributeName:  "organizationId",
+				AttributeValue: strconv.Itoa(product.OrganizationID),
+			},
+		}
+	}
+	return []domain.ProductError{
+		{
+			AttributeName:  "containerId",
+			AttributeValue: strconv.Itoa(product.ContainerID),
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	getCustomFieldsUri = "/account/metadata"
+)
+
+// customFieldPatterns maps DigiCert custom field data types to the pattern their values must match
+var customFieldPatterns = map[string]string{
+	"text":          `^[^\r\n]*$`,
+	"int":           `^-?[0-9]+$`,
+	"email_address": `^[^@\s,]+@[^@\s,]+\.[^@\s,]+$`,
+	"email_list":    `^[^@\s,]+@[^@\s,]+\.[^@\s,]+(\s*,\s*[^@\s,]+@[^@\s,]+\.[^@\s,]+)*$`,
+}
+
+type digicertCustomField struct {
+	ID         int    `json:"id"`
+	Label      string `json:"label"`
+	IsRequired bool   `json:"is_required"`
+	IsActive   bool   `json:"is_active"`
+	DataType   string `json:"data_type"`
+}
+
+type getCustomFieldsResponse struct {
+	Metadata []digicertCustomField `json:"metadata"`
+}
+
+// getCustomFields retrieves the active custom order fields of the account, except the one reserved for idempotency keys
+func getCustomFields(ctx context.Context, connection domain.Connection) ([]domain.CustomField, error) {
+	resp, err := executeRequest(ctx, connection, nil, getCustomFieldsUri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	customFieldsResponse := getCustomFieldsResponse{}
+	err = json.Unmarshal(resp.Body(), &customFieldsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	customFields := make([]domain.CustomField, 0)
+	for _, field := range customFieldsResponse.Metadata {
+		if !field.IsActive || field.ID == connection.Configuration.IdempotencyFieldID {
+			continue
+		}
+		customFields = append(customFields, domain.CustomField{
+			ID:       field.ID,
+			Label:    field.Label,
+			Required: field.IsRequired,
+			DataType: field.DataType,
+			Pattern:  customFieldPatterns[field.DataType],
+		})
+	}
+	return customFields, nil
+}
+
+// validateCustomFields checks that required custom fields have a value and that values match their field constraints
+func validateCustomFields(option domain.ProductOption, product domain.Product) []domain.ProductError {
+	var errors []domain.ProductError
+
+	values := make(map[int]string)
+	for _, value := range product.CustomFields {
+		values[value.ID] = value.Value
+	}
+
+	fields := make(map[int]domain.CustomField)
+	for _, field := range option.Details.CustomFields {
+		fields[field.ID] = field
+		if value, ok := values[field.ID]; field.Required && (!ok || value == "") {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  customFieldAttributeName(field.ID),
+				AttributeValue: value,
+			})
+		}
+	}
+
+	for _, value := range product.CustomFields {
+		field, ok := fields[value.ID]
+		if !ok {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  customFieldAttributeName(value.ID),
+				AttributeValue: value.Value,
+			})
+			continue
+		}
+		if value.Value == "" || field.Pattern == "" {
+			continue
+		}
+		if matched, err := regexp.MatchString(field.Pattern, value.Value); err != nil || !matched {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  customFieldAttributeName(value.ID),
+				AttributeValue: value.Value,
+			})
+		}
+	}
+	return errors
+}
+
+func customFieldAttributeName(id int) string {
+	return fmt.Sprintf("customFields[%d]", id)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+// TestValidateCustomFields ...
+func TestValidateCustomFields(t *testing.T) {
+	option := domain.ProductOption{
+		Details: domain.ProductDetails{
+			CustomFields: []domain.CustomField{
+				{
+					ID:       1,
+					Label:    "Cost Center",
+					Required: true,
+					DataType: "int",
+					Pattern:  customFieldPatterns["int"],
+				},
+				{
+					ID:       2,
+					Label:    "Notify",
+					DataType: "email_address",
+					Pattern:  customFieldPatterns["email_address"],
+				},
+				{
+					ID:       3,
+					Label:    "Anything",
+					DataType: "anything",
+				},
+			},
+		},
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		errors := validateCustomFields(option, domain.Product{
+			CustomFields: []domain.CustomFieldValue{
+				{ID: 1, Value: "-12"},
+				{ID: 2, Value: "admin@example.com"},
+				{ID: 3, Value: "multi\nline"},
+			},
+		})
+		require.Empty(t, errors)
+	})
+
+	t.Run("missingRequired", func(t *testing.T) {
+		errors := validateCustomFields(option, domain.Product{
+			CustomFields: []domain.CustomFieldValue{
+				{ID: 2, Value: ""},
+			},
+		})
+		require.Equal(t, []domain.ProductError{
+			{
+				AttributeName:  "customFields[1]",
+				AttributeValue: "",
+			},
+		}, errors)
+	})
+
+	t.Run("invalidValues", func(t *testing.T) {
+		errors := validateCustomFields(option, domain.Product{
+			CustomFields: []domain.CustomFieldValue{
+				{ID: 1, Value: "1.5"},
+				{ID: 2, Value: "admin@example.com, other@example.com"},
+				{ID: 4, Value: "unknown"},
+			},
+		})
+		require.Equal(t, []domain.ProductError{
+			{
+				AttributeName:  "customFields[1]",
+				AttributeValue: "1.5",
+			},
+			{
+				AttributeName:  "customFields[2]",
+				AttributeValue: "admin@example.com, other@example.com",
+			},
+			{
+				AttributeName:  "customFields[4]",
+				AttributeValue: "unknown",
+			},
+		}, errors)
+	})
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	checkDcvUri = "/order/certificate/%s/check-dcv"
+	// DefaultDcvCheckInterval is the default minimum time between two DCV checks triggered for the same order
+	DefaultDcvCheckInterval = 5 * time.Minute
+)
+
+type digicertCheckDcvResponse struct {
+	OrderStatus   string `json:"order_status"`
+	DcvStatus     string `json:"dcv_status"`
+	CertificateID int    `json:"certificate_id"`
+}
+
+type dcvCheckEntry struct {
+	at    time.Time
+	check domain.DcvCheck
+}
+
+// dcvCheckThrottle remembers the last DCV check of each order, so DigiCert is asked at most once per interval
+type dcvCheckThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	entries  map[string]dcvCheckEntry
+}
+
+func newDcvCheckThrottle(interval time.Duration) *dcvCheckThrottle {
+	return &dcvCheckThrottle{
+		interval: interval,
+		entries:  make(map[string]dcvCheckEntry),
+	}
+}
+
+// recent returns the last check of the order when it happened within the interval
+func (t *dcvCheckThrottle) recent(key string, now time.Time) (*domain.DcvCheck, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok || now.Sub(entry.at) >= t.interval {
+		return nil, false
+	}
+	check := entry.check
+	return &check, true
+}
+
+func (t *dcvCheckThrottle) record(key string, now time.Time, check domain.DcvCheck) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k, entry := range t.entries {
+		if now.Sub(entry.at) >= t.interval {
+			delete(t.entries, k)
+		}
+	}
+	t.entries[key] = dcvCheckEntry{at: now, check: check}
+}
+
+// checkDcv asks DigiCert to check domain control validation of the order, unless it was already asked within the throttle interval
+func (cs *Certificate) checkDcv(ctx context.Context, connection domain.Connection, id string) (*domain.DcvCheck, *digicertCheckDcvResponse) {
+	key := connection.Configuration.ServerURL + "/" + id
+	now := time.Now()
+	if check, ok := cs.dcvChecks.recent(key, now); ok {
+		return check, nil
+	}
+
+	check := domain.DcvCheck{
+		CheckedAt: now.UTC().Format(time.RFC3339),
+	}
+	var digicertResponse *digicertCheckDcvResponse
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(checkDcvUri, id), http.MethodPut)
+	if err == nil {
+		digicertResponse = &digicertCheckDcvResponse{}
+		err = json.Unmarshal(resp.Body(), digicertResponse)
+	}
+	if err != nil {
+		zap.L().Error(fmt.Sprintf("failed to check domain control validation of DigiCert order '%s'", id), zap.Error(err))
+		check.Status = "failed"
+		check.ErrorMessage = fmt.Sprintf("failed to check domain control validation: %s", err.Error())
+		digicertResponse = nil
+	} else {
+		check.Status = digicertResponse.DcvStatus
+	}
+
+	cs.dcvChecks.record(key, now, check)
+	return &check, digicertResponse
+}
+
+// hasPendingTokenValidation reports whether any domain waits for a DNS or HTTP token, which DigiCert can check on demand
+func hasPendingTokenValidation(validations []domain.DomainValidation) bool {
+	for _, validation := range validations {
+		if validation.Method != domain.DcvMethodEmail && validation.Method != "" && validation.Status == "pending" {
+			return true
+		}
+	}
+	return false
+}
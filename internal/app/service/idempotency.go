@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultIdempotencyWindow is how long a submitted certificate request is remembered
+	DefaultIdempotencyWindow = 24 * time.Hour
+	// idempotencyLookupLimit is the number of recent orders searched for an idempotency key tag
+	idempotencyLookupLimit   = 10
+	searchOrdersByCommonName = "/order/certificate?filters[common_name]=%s&sort=-order_id&limit=%d"
+)
+
+// IdempotencyRecord contains the outcome of a certificate request submitted to the Certificate Authority
+type IdempotencyRecord struct {
+	CreatedAt          time.Time                  `json:"createdAt"`
+	CertificateDetails *domain.CertificateDetails `json:"certificateDetails,omitempty"`
+	OrderDetails       *domain.OrderDetails       `json:"orderDetails,omitempty"`
+	// Uncertain is set when the request failed after it may have reached DigiCert, which may have placed the order
+	Uncertain bool `json:"uncertain,omitempty"`
+}
+
+// IdempotencyStore remembers recently submitted certificate requests by idempotency key
+type IdempotencyStore interface {
+	// Get returns the record stored for the key, or nil when there is none within the store window
+	Get(key string) (*IdempotencyRecord, error)
+	// Put stores the record for the key
+	Put(key string, record IdempotencyRecord) error
+}
+
+// MemoryIdempotencyStore keeps idempotency records in memory
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	window  time.Duration
+	records map[string]IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore will return an in-memory idempotency store keeping records for the window duration
+func NewMemoryIdempotencyStore(window time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		window:  window,
+		records: make(map[string]IdempotencyRecord),
+	}
+}
+
+// Get returns the record stored for the key, or nil when there is none within the store window
+func (s *MemoryIdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || time.Since(record.CreatedAt) >= s.window {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores the record for the key and drops the expired ones
+func (s *MemoryIdempotencyStore) Put(key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruneIdempotencyRecords(s.records, s.window)
+	s.records[key] = record
+	return nil
+}
+
+// FileIdempotencyStore keeps idempotency records in a JSON file, so they survive a connector restart
+type FileIdempotencyStore struct {
+	mu      sync.Mutex
+	path    string
+	window  time.Duration
+	records map[string]IdempotencyRecord
+}
+
+// NewFileIdempotencyStore will return an idempotency store persisted to the file at path, keeping records for the window duration
+func NewFileIdempotencyStore(path string, window time.Duration) (*FileIdempotencyStore, error) {
+	store := &FileIdempotencyStore{
+		path:    path,
+		window:  window,
+		records: make(map[string]IdempotencyRecord),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read idempotency store %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &store.records); err != nil {
+			return nil, fmt.Errorf("failed to parse idempotency store %s: %w", path, err)
+		}
+	}
+	return store, nil
+}
+
+// Get returns the record stored for the key, or nil when there is none within the store window
+func (s *FileIdempotencyStore) Get(key string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || time.Since(record.CreatedAt) >= s.window {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Put stores the record for the key, drops the expired ones and rewrites the file
+func (s *FileIdempotencyStore) Put(key string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruneIdempotencyRecords(s.records, s.window)
+	s.records[key] = record
+
+	data, err := json.Marshal(s.records)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so a crash never leaves a truncated store behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func pruneIdempotencyRecords(records map[string]IdempotencyRecord, window time.Duration) {
+	for key, record := range records {
+		if time.Since(record.CreatedAt) >= window {
+			delete(records, key)
+		}
+	}
+}
+
+// keyedMutex serializes concurrent certificate requests sharing an idempotency key
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedMutexEntry
+}
+
+type keyedMutexEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedMutexEntry)}
+}
+
+// lock acquires the lock of the key and returns the function releasing it
+func (km *keyedMutex) lock(key string) func() {
+	km.mu.Lock()
+	entry, ok := km.locks[key]
+	if !ok {
+		entry = &keyedMutexEntry{}
+		km.locks[key] = entry
+	}
+	entry.refs++
+	km.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+		km.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(km.locks, key)
+		}
+		km.mu.Unlock()
+	}
+}
+
+// idempotencyKey hashes everything identifying a certificate request: the CSR, the product and the connection
+func idempotencyKey(connection domain.Connection, pkcs10Request string, product domain.Product, productOptionName string) string {
+	productData, _ := json.Marshal(product)
+	apiKeyHash := sha256.Sum256([]byte(connection.Credentials.ApiKey))
+
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(strings.Join(strings.Fields(pkcs10Request), "")),
+		productData,
+		[]byte(productOptionName),
+		[]byte(connection.Configuration.ServerURL),
+		apiKeyHash[:],
+	} {
+		h.Write([]byte(strconv.Itoa(len(part))))
+		h.Write([]byte{':'})
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// findTaggedOrder searches the recent orders for the common name for one tagged with the idempotency key.
+// The orders are only read, a lookup must neither change them nor use up the DCV check throttle
+func (cs *Certificate) findTaggedOrder(ctx context.Context, connection domain.Connection, commonName string, key string) *domain.OrderDetails {
+	fieldID := connection.Configuration.IdempotencyFieldID
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(searchOrdersByCommonName, url.QueryEscape(commonName), idempotencyLookupLimit), http.MethodGet)
+	if err != nil {
+		zap.L().Warn("failed to search DigiCert orders for idempotency key", zap.Error(err))
+		return nil
+	}
+
+	searchResponse := digicertOrderDetailsSearchResponse{}
+	if err = json.Unmarshal(resp.Body(), &searchResponse); err != nil {
+		zap.L().Warn("failed to unmarshal DigiCert orders search response", zap.Error(err))
+		return nil
+	}
+
+	for _, order := range searchResponse.Orders {
+		resp, err = executeRequest(ctx, connection, nil, fmt.Sprintf(orderCertificateUri, strconv.Itoa(order.ID)), http.MethodGet)
+		if err != nil {
+			continue
+		}
+		orderDetails := digiCertOrderDetails{}
+		if err = json.Unmarshal(resp.Body(), &orderDetails); err != nil {
+			continue
+		}
+		for _, field := range orderDetails.CustomFields {
+			if field.MetadataID == fieldID && field.Value == key {
+				details := mapOrderDetails(strconv.Itoa(order.ID), orderDetails)
+				return &details
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	idempotencyFieldID = 77
+)
+
+// TestIdempotencyStore ...
+func TestIdempotencyStore(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testIdempotencyStore(t, NewMemoryIdempotencyStore(time.Hour))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "idempotency.json")
+		store, err := NewFileIdempotencyStore(path, time.Hour)
+		require.NoError(t, err)
+		testIdempotencyStore(t, store)
+
+		// records survive reopening the store
+		reopened, err := NewFileIdempotencyStore(path, time.Hour)
+		require.NoError(t, err)
+		record, err := reopened.Get("key")
+		require.NoError(t, err)
+		require.Equal(t, "1234", record.OrderDetails.ID)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := NewMemoryIdempotencyStore(time.Hour)
+		err := store.Put("key", IdempotencyRecord{
+			CreatedAt:    time.Now().Add(-2 * time.Hour),
+			OrderDetails: &domain.OrderDetails{ID: "1234"},
+		})
+		require.NoError(t, err)
+		record, err := store.Get("key")
+		require.NoError(t, err)
+		require.Nil(t, record)
+	})
+}
+
+// TestRequestCertificateIdempotency ...
+func TestRequestCertificateIdempotency(t *testing.T) {
+	t.Run("duplicateRequest", func(t *testing.T) {
+		testRequestCertificateDuplicate(t)
+	})
+
+	t.Run("taggedOrderFound", func(t *testing.T) {
+		testRequestCertificateTagged(t, true)
+	})
+
+	t.Run("taggedOrderNotFound", func(t *testing.T) {
+		testRequestCertificateTagged(t, false)
+	})
+
+	t.Run("malformedResponse", func(t *testing.T) {
+		// DigiCert placed the order although its answer cannot be read, the retry must not place another one
+		testRequestCertificateFailedRetry(t, httpmock.NewStringResponder(http.StatusCreated, `{"id":`), 1)
+	})
+
+	t.Run("serverError", func(t *testing.T) {
+		testRequestCertificateFailedRetry(t, httpmock.NewStringResponder(http.StatusBadGateway, `Bad Gateway`), 1)
+	})
+
+	t.Run("refusedRequest", func(t *testing.T) {
+		// a request DigiCert refused placed no order and is submitted again
+		testRequestCertificateFailedRetry(t, httpmock.NewStringResponder(http.StatusBadRequest,
+			`{"errors":[{"code":"invalid_csr","message":"Invalid CSR."}]}`), 2)
+	})
+}
+
+func testRequestCertificateFailedRetry(t *testing.T, responder httpmock.Responder, expectedOrders int) {
+	connection := buildConnection()
+	product := domain.Product{
+		OrganizationID: productOrganizationId,
+		HashAlgorithm:  productHashAlgorithm,
+	}
+	productDetails := &domain.ProductDetails{NameID: "ssl_private_id"}
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	orders := 0
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderCertificateUri, productDetails.NameID),
+		func(req *http.Request) (*http.Response, error) {
+			orders++
+			return responder(req)
+		},
+	)
+
+	certService := NewCertificateService()
+	for i := 0; i < 2; i++ {
+		cert, order, err := certService.RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+		require.NoError(t, err)
+		require.Nil(t, order)
+		require.Equal(t, domain.CertificateStatusFailed, cert.Status)
+	}
+	require.Equal(t, expectedOrders, orders)
+}
+
+func testIdempotencyStore(t *testing.T, store IdempotencyStore) {
+	record, err := store.Get("key")
+	require.NoError(t, err)
+	require.Nil(t, record)
+
+	err = store.Put("key", IdempotencyRecord{
+		CreatedAt:    time.Now(),
+		OrderDetails: &domain.OrderDetails{ID: "1234", Status: domain.OrderStatusProcessing},
+	})
+	require.NoError(t, err)
+
+	record, err = store.Get("key")
+	require.NoError(t, err)
+	require.Equal(t, "1234", record.OrderDetails.ID)
+	require.Nil(t, record.CertificateDetails)
+}
+
+func testRequestCertificateDuplicate(t *testing.T) {
+	connection := buildConnection()
+	product := domain.Product{
+		OrganizationID: productOrganizationId,
+		HashAlgorithm:  productHashAlgorithm,
+	}
+	productDetails := &domain.ProductDetails{NameID: "ssl_private_id"}
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	orders := 0
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderCertificateUri, productDetails.NameID),
+		func(req *http.Request) (*http.Response, error) {
+			orders++
+			return httpmock.NewJsonResponse(http.StatusOK, &digiCertRequestCertificateResponse{
+				ID: 1234 + orders,
+			})
+		},
+	)
+
+	certService := NewCertificateService()
+	_, first, err := certService.RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+	require.NoError(t, err)
+	_, second, err := certService.RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+	require.NoError(t, err)
+	require.Equal(t, 1, orders)
+	require.Equal(t, first, second)
+
+	// a different product is a different request
+	product.AutoRenew = true
+	_, third, err := certService.RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+	require.NoError(t, err)
+	require.Equal(t, 2, orders)
+	require.NotEqual(t, first.ID, third.ID)
+}
+
+func testRequestCertificateTagged(t *testing.T, found bool) {
+	connection := buildConnection()
+	connection.Configuration.IdempotencyFieldID = idempotencyFieldID
+	connection.Configuration.CheckDcv = true
+	product := domain.Product{
+		OrganizationID: productOrganizationId,
+		HashAlgorithm:  productHashAlgorithm,
+	}
+	productDetails := &domain.ProductDetails{NameID: "ssl_private_id"}
+	key := idempotencyKey(connection, pkcs10Request, product, productOptionName)
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(searchOrdersByCommonName, "digicert-test.com", idempotencyLookupLimit),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, &digicertOrderDetailsSearchResponse{
+				Orders: []digiCertOrderDetails{{ID: 999}},
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderCertificateUri, "999"),
+		func(req *http.Request) (*http.Response, error) {
+			value := "another-key"
+			if found {
+				value = key
+			}
+			return httpmock.NewJsonResponse(http.StatusOK, &digiCertOrderDetails{
+				ID:           999,
+				Status:       "pending",
+				DcvMethod:    domain.DcvMethodDnsTxtToken,
+				Domains:      []orderDomain{{ID: 1, Name: "digicert-test.com", DcvStatus: "pending"}},
+				CustomFields: []customField{{MetadataID: idempotencyFieldID, Value: value}},
+			})
+		},
+	)
+	// the lookup must not check the DCV of the orders it finds
+	httpmock.RegisterResponder("PUT", serverURL+fmt.Sprintf(checkDcvUri, "999"),
+		func(req *http.Request) (*http.Response, error) {
+			t.Error("unexpected DCV check of order 999")
+			return httpmock.NewJsonResponse(http.StatusOK, &digicertCheckDcvResponse{})
+		},
+	)
+
+	orders := 0
+	httpmock.RegisterResponder("POST", serverURL+fmt.Sprintf(orderCertificateUri, productDetails.NameID),
+		func(req *http.Request) (*http.Response, error) {
+			orders++
+			data, err := io.ReadAll(req.Body)
+			assert.NoError(t, err)
+			reqBody := &newCertificateRequestBody{}
+			err = json.Unmarshal(data, reqBody)
+			assert.NoError(t, err)
+			assert.Equal(t, []customField{{MetadataID: idempotencyFieldID, Value: key}}, reqBody.CustomFields)
+
+			return httpmock.NewJsonResponse(http.StatusOK, &digiCertRequestCertificateResponse{
+				ID: 1234,
+			})
+		},
+	)
+
+	_, order, err := NewCertificateService().RequestCertificate(context.Background(), connection, pkcs10Request, product, productOptionName, 300, productDetails)
+	require.NoError(t, err)
+	if found {
+		require.Equal(t, 0, orders)
+		require.Equal(t, "999", order.ID)
+		require.Equal(t, domain.OrderStatusProcessing, order.Status)
+	} else {
+		require.Equal(t, 1, orders)
+		require.Equal(t, "1234", order.ID)
+	}
+}
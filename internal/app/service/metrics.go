@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// identifierSegmentLength is the length from which a hexadecimal path segment is taken for an identifier, such
+// as a certificate serial number, even when it has no digit
+const identifierSegmentLength = 24
+
+var (
+	digicertRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "digicert_requests_total",
+		Help: "Number of requests sent to the DigiCert API, by endpoint template and status class.",
+	}, []string{"endpoint", "status"})
+
+	digicertRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "digicert_request_retries_total",
+		Help: "Number of requests sent to the DigiCert API again after a failure, by endpoint template.",
+	}, []string{"endpoint"})
+
+	importBatchCertificates = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "import_batch_certificates",
+		Help:    "Number of certificates returned per import batch.",
+		Buckets: []float64{0, 1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+)
+
+// endpointTemplate reduces a DigiCert API path to its template, replacing identifiers with {id} and
+// dropping the query, so that it can be used as a metric label
+func endpointTemplate(uriPath string) string {
+	if u, err := url.Parse(uriPath); err == nil {
+		uriPath = u.Path
+	}
+	segments := strings.Split(uriPath, "/")
+	for i, segment := range segments {
+		if isIdentifier(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isIdentifier tells whether a path segment is a numeric or hexadecimal identifier. Words made of hexadecimal
+// letters only, such as "add", are not unless they are as long as a serial number
+func isIdentifier(segment string) bool {
+	if segment == "" || strings.IndexFunc(segment, func(r rune) bool { return !unicode.Is(unicode.ASCII_Hex_Digit, r) }) >= 0 {
+		return false
+	}
+	return len(segment) >= identifierSegmentLength || strings.IndexFunc(segment, unicode.IsDigit) >= 0
+}
+
+// statusClass is the class of an HTTP status, such as 2xx, or error when no response was received
+func statusClass(status int) string {
+	if status == 0 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
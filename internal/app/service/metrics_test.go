@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := []struct {
+		uriPath  string
+		expected string
+	}{
+		{uriPath: "/organization", expected: "/organization"},
+		{uriPath: "/organization?container_id=12", expected: "/organization"},
+		{uriPath: "/order/certificate/1234", expected: "/order/certificate/{id}"},
+		{uriPath: "/order/certificate/1234/duplicate", expected: "/order/certificate/{id}/duplicate"},
+		{uriPath: "/order/certificate?filters[status]=issued&limit=10&offset=0&sort=order_id", expected: "/order/certificate"},
+		{uriPath: "/certificate/12/download/format/pem_all", expected: "/certificate/{id}/download/format/pem_all"},
+		{uriPath: "/certificate/12/download/format/p7b", expected: "/certificate/{id}/download/format/p7b"},
+		{uriPath: "/services/v2/order/certificate/1234", expected: "/services/v2/order/certificate/{id}"},
+		{uriPath: "/certificate/0A1B2C3D4E5F/revoke", expected: "/certificate/{id}/revoke"},
+		{uriPath: "/request/abcdefabcdefabcdefabcdefab", expected: "/request/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uriPath, func(t *testing.T) {
+			require.Equal(t, tt.expected, endpointTemplate(tt.uriPath))
+		})
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	require.Equal(t, "2xx", statusClass(201))
+	require.Equal(t, "5xx", statusClass(503))
+	require.Equal(t, "error", statusClass(0))
+}
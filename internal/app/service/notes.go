@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"text/template"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	orderNoteUri = "/order/certificate/%d/note"
+
+	// defaultNoteTemplate is used when the product does not define its own order note template
+	defaultNoteTemplate = "Requested via TLS Protect Cloud for {{.CommonName}} using product option {{.ProductOptionName}}"
+)
+
+var emailPattern = regexp.MustCompile(customFieldPatterns["email_address"])
+
+type orderNoteBody struct {
+	Text string `json:"text"`
+}
+
+// orderNoteData contains the values available to order note template placeholders
+type orderNoteData struct {
+	CommonName        string
+	ProductOptionName string
+}
+
+// renderOrderNote renders the order note template of the product, falling back to the default template
+func renderOrderNote(noteTemplate string, data orderNoteData) (string, error) {
+	if noteTemplate == "" {
+		noteTemplate = defaultNoteTemplate
+	}
+	tmpl, err := template.New("note").Option("missingkey=error").Parse(noteTemplate)
+	if err != nil {
+		return "", err
+	}
+	var note bytes.Buffer
+	if err = tmpl.Execute(&note, data); err != nil {
+		return "", err
+	}
+	return note.String(), nil
+}
+
+// orderNote returns the note recorded on the order, so that it always shows it was requested through the connector
+func orderNote(product domain.Product, commonName string, productOptionName string) string {
+	data := orderNoteData{
+		CommonName:        commonName,
+		ProductOptionName: productOptionName,
+	}
+	note, err := renderOrderNote(product.NoteTemplate, data)
+	if err != nil {
+		zap.L().Warn("failed to render order note template, using default template", zap.Error(err))
+		note, _ = renderOrderNote(defaultNoteTemplate, data)
+	}
+	return note
+}
+
+// addOrderNote records the note on the order; the order has already been placed, so failures are only logged
+func addOrderNote(ctx context.Context, connection domain.Connection, orderID int, note string) {
+	_, err := executeRequest(ctx, connection, orderNoteBody{Text: note}, fmt.Sprintf(orderNoteUri, orderID), http.MethodPost)
+	if err != nil {
+		zap.L().Warn("failed to add note to DigiCert order", zap.Int("orderId", orderID), zap.Error(err))
+	}
+}
+
+// validateOrderAnnotations checks the note template and the additional notification emails of the product
+func validateOrderAnnotations(product domain.Product) []domain.ProductError {
+	var errors []domain.ProductError
+	if product.NoteTemplate != "" {
+		if _, err := renderOrderNote(product.NoteTemplate, orderNoteData{}); err != nil {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  "noteTemplate",
+				AttributeValue: product.NoteTemplate,
+			})
+		}
+	}
+	for _, email := range product.AdditionalEmails {
+		if !emailPattern.MatchString(email) {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  "additionalEmails",
+				AttributeValue: email,
+			})
+		}
+	}
+	return errors
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+// TestRenderOrderNote ...
+func TestRenderOrderNote(t *testing.T) {
+	data := orderNoteData{
+		CommonName:        "www.example.com",
+		ProductOptionName: "SSL Certificates",
+	}
+
+	t.Run("defaultTemplate", func(t *testing.T) {
+		note, err := renderOrderNote("", data)
+		require.NoError(t, err)
+		require.Equal(t, "Requested via TLS Protect Cloud for www.example.com using product option SSL Certificates", note)
+	})
+
+	t.Run("customTemplate", func(t *testing.T) {
+		note, err := renderOrderNote("{{.ProductOptionName}}: {{.CommonName}}", data)
+		require.NoError(t, err)
+		require.Equal(t, "SSL Certificates: www.example.com", note)
+	})
+
+	t.Run("unknownPlaceholder", func(t *testing.T) {
+		_, err := renderOrderNote("{{.Requester}}", data)
+		require.Error(t, err)
+	})
+
+	t.Run("fallbackToDefault", func(t *testing.T) {
+		note := orderNote(domain.Product{NoteTemplate: "{{.Requester"}, data.CommonName, data.ProductOptionName)
+		require.Equal(t, "Requested via TLS Protect Cloud for www.example.com using product option SSL Certificates", note)
+	})
+}
+
+// TestValidateOrderAnnotations ...
+func TestValidateOrderAnnotations(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		errors := validateOrderAnnotations(domain.Product{
+			NoteTemplate:     "Requested for {{.CommonName}}",
+			AdditionalEmails: []string{"team@example.com"},
+		})
+		require.Empty(t, errors)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		errors := validateOrderAnnotations(domain.Product{
+			NoteTemplate:     "{{.Requester}}",
+			AdditionalEmails: []string{"team@example.com", "not-an-email"},
+		})
+		require.Equal(t, []domain.ProductError{
+			{
+				AttributeName:  "noteTemplate",
+				AttributeValue: "{{.Requester}}",
+			},
+			{
+				AttributeName:  "additionalEmails",
+				AttributeValue: "not-an-email",
+			},
+		}, errors)
+	})
+}
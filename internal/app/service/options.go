@@ -1,12 +1,14 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
 )
 
 const (
@@ -19,6 +21,8 @@ type organization struct {
 	Name     string `json:"name"`
 	Status   string `json:"status"`
 	IsActive bool   `json:"is_active"`
+	// Container is the container (division) the organization belongs to
+	Container *container `json:"container"`
 }
 
 type getOrganizationsResponse struct {
@@ -40,6 +44,23 @@ type digiCertProductDetails struct {
 	CertificateType string             `json:"type"`
 	Name            string             `json:"name"`
 	NameID          string             `json:"name_id"`
+	AllowAutoRenew  bool               `json:"allow_auto_renew"`
+	ValidationType  string             `json:"validation_type"`
+}
+
+// codeSigningProvisioningMethods lists the key provisioning methods accepted for code signing orders
+var codeSigningProvisioningMethods = []string{
+	domain.CsProvisioningMethodClientApp,
+	domain.CsProvisioningMethodShipToken,
+	domain.CsProvisioningMethodExistingToken,
+}
+
+// domainControlValidationMethods lists the domain control validation methods accepted for SSL orders
+var domainControlValidationMethods = []string{
+	domain.DcvMethodEmail,
+	domain.DcvMethodDnsTxtToken,
+	domain.DcvMethodDnsCnameToken,
+	domain.DcvMethodHttpToken,
 }
 
 type getProductDetails struct {
@@ -55,36 +76,58 @@ func NewOptionsService() *Options {
 	return &Options{}
 }
 
-// GetOptions will retrieve product and import options from Certificate Authority
-func (cs *Options) GetOptions(connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
+// unavailableOptions tells which of the optional lists could not be retrieved, the options going without them.
+// An unavailable list is not the same as an empty one, the product attributes it holds cannot be validated
+type unavailableOptions struct {
+	customFields    bool
+	containers      bool
+	serverPlatforms bool
+}
+
+// GetOptions will retrieve product and import options from Certificate Authority. Only the products and the
+// organizations are required, the API key may not be allowed to list the rest and the options then go without
+func (cs *Options) GetOptions(ctx context.Context, connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, error) {
+	productOptions, importOptions, _, err := cs.getOptions(ctx, connection)
+	return productOptions, importOptions, err
+}
 
-	resp, err := executeRequest(connection, nil, getOrganizationsUri, http.MethodGet)
+func (cs *Options) getOptions(ctx context.Context, connection domain.Connection) ([]domain.ProductOption, []domain.ImportOption, unavailableOptions, error) {
+	var unavailable unavailableOptions
 
+	organizations, err := getActiveOrganizations(ctx, connection)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, unavailable, err
 	}
+	activeOrganizations := organizationIDs(organizations)
 
-	orgResponse := getOrganizationsResponse{}
-	err = json.Unmarshal(resp.Body(), &orgResponse)
+	customFields, err := getCustomFields(ctx, connection)
 	if err != nil {
-		return nil, nil, err
+		zap.L().Warn("failed to retrieve DigiCert custom fields, options go without", zap.Error(err))
+		unavailable.customFields = true
 	}
 
-	activeOrganizations := make([]int, 0)
-	for _, org := range orgResponse.Organizations {
-		if org.IsActive {
-			activeOrganizations = append(activeOrganizations, org.ID)
-		}
+	containers, err := getContainerOptions(ctx, connection, organizations)
+	if err != nil {
+		zap.L().Warn("failed to retrieve DigiCert containers, options go without", zap.Error(err))
+		containers = &containerOptions{}
+		unavailable.containers = true
+	}
+
+	serverPlatforms, err := getServerPlatforms(ctx, connection)
+	if err != nil {
+		zap.L().Warn("failed to retrieve DigiCert server platforms, options go without", zap.Error(err))
+		unavailable.serverPlatforms = true
 	}
-	resp, err = executeRequest(connection, nil, getProductUri, http.MethodGet)
+
+	resp, err := executeRequest(ctx, connection, nil, getProductUri, http.MethodGet)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, unavailable, err
 	}
 
 	productResponse := getProductDetails{}
 	err = json.Unmarshal(resp.Body(), &productResponse)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, unavailable, err
 	}
 
 	productOptions := make([]domain.ProductOption, 0)
@@ -92,8 +135,14 @@ func (cs *Options) GetOptions(connection domain.Connection) ([]domain.ProductOpt
 	for _, product := range productResponse.ProductDetails {
 		if product.CertificateType == "ssl_certificate" || product.CertificateType == "code_signing_certificate" {
 			productType := domain.ProductTypeSsl
+			var csProvisioningMethods []string
+			dcvMethods := domainControlValidationMethods
+			platforms := serverPlatforms
 			if product.CertificateType == "code_signing_certificate" {
 				productType = domain.ProductTypeCodeSign
+				csProvisioningMethods = codeSigningProvisioningMethods
+				dcvMethods = nil
+				platforms = nil
 			}
 			hashes := make([]string, 0)
 			for _, hash := range product.Hashes.AllowedHashTypes {
@@ -103,10 +152,17 @@ func (cs *Options) GetOptions(connection domain.Connection) ([]domain.ProductOpt
 				Name:  product.Name,
 				Types: []domain.ProductType{productType},
 				Details: domain.ProductDetails{
-					Hashes:               hashes,
-					DefaultHashAlgorithm: product.Hashes.DefaultHashType,
-					NameID:               product.NameID,
-					Organizations:        activeOrganizations,
+					Hashes:                hashes,
+					DefaultHashAlgorithm:  product.Hashes.DefaultHashType,
+					NameID:                product.NameID,
+					Organizations:         activeOrganizations,
+					AllowAutoRenew:        product.AllowAutoRenew,
+					ValidationType:        product.ValidationType,
+					CsProvisioningMethods: csProvisioningMethods,
+					DcvMethods:            dcvMethods,
+					CustomFields:          customFields,
+					Containers:            containers.forProduct(product.NameID),
+					ServerPlatforms:       platforms,
 				},
 			})
 
@@ -120,13 +176,43 @@ func (cs *Options) GetOptions(connection domain.Connection) ([]domain.ProductOpt
 		}
 
 	}
-	return productOptions, importOptions, nil
+	return productOptions, importOptions, unavailable, nil
+}
+
+// getActiveOrganizations retrieves the active organizations together with the container each of them belongs to
+func getActiveOrganizations(ctx context.Context, connection domain.Connection) ([]organization, error) {
+	resp, err := executeRequest(ctx, connection, nil, getOrganizationsUri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	orgResponse := getOrganizationsResponse{}
+	err = json.Unmarshal(resp.Body(), &orgResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	activeOrganizations := make([]organization, 0)
+	for _, org := range orgResponse.Organizations {
+		if org.IsActive {
+			activeOrganizations = append(activeOrganizations, org)
+		}
+	}
+	return activeOrganizations, nil
+}
+
+func organizationIDs(organizations []organization) []int {
+	ids := make([]int, 0, len(organizations))
+	for _, org := range organizations {
+		ids = append(ids, org.ID)
+	}
+	return ids
 }
 
 // ValidateProduct will validate product against Certificate Authority
-func (cs *Options) ValidateProduct(connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error) {
+func (cs *Options) ValidateProduct(ctx context.Context, connection domain.Connection, name string, product domain.Product) ([]domain.ProductError, error) {
 
-	options, _, err := cs.GetOptions(connection)
+	options, _, unavailable, err := cs.getOptions(ctx, connection)
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +249,30 @@ func (cs *Options) ValidateProduct(connection domain.Connection, name string, pr
 					AttributeValue: strconv.Itoa(product.OrganizationID),
 				})
 			}
+			if product.AutoRenew && !option.Details.AllowAutoRenew {
+				errors = append(errors, domain.ProductError{
+					AttributeName:  "autoRenew",
+					AttributeValue: strconv.FormatBool(product.AutoRenew),
+				})
+			}
+			if orgExist && !unavailable.containers {
+				errors = append(errors, validateContainer(option, product)...)
+			}
+			errors = append(errors, validateCodeSigning(option, product)...)
+			if !unavailable.customFields {
+				errors = append(errors, validateCustomFields(option, product)...)
+			}
+			errors = append(errors, validateOrderAnnotations(product)...)
+			// code signing products have no server platform whether the platforms are available or not
+			if !unavailable.serverPlatforms || option.Types[0] == domain.ProductTypeCodeSign {
+				errors = append(errors, validateServerPlatform(option, product)...)
+			}
+			if product.DcvMethod != "" && !contains(option.Details.DcvMethods, product.DcvMethod) {
+				errors = append(errors, domain.ProductError{
+					AttributeName:  "dcvMethod",
+					AttributeValue: product.DcvMethod,
+				})
+			}
 			break
 		}
 	}
@@ -175,3 +285,48 @@ func (cs *Options) ValidateProduct(connection domain.Connection, name string, pr
 
 	return errors, nil
 }
+
+// validateCodeSigning checks the code signing attributes, which are rejected on the other products. No provisioning
+// method leaves the choice to DigiCert, as for the products configured before the method could be chosen
+func validateCodeSigning(option domain.ProductOption, product domain.Product) []domain.ProductError {
+	var errors []domain.ProductError
+	if product.CsProvisioningMethod != "" && !contains(option.Details.CsProvisioningMethods, product.CsProvisioningMethod) {
+		errors = append(errors, domain.ProductError{
+			AttributeName:  "csProvisioningMethod",
+			AttributeValue: product.CsProvisioningMethod,
+		})
+	}
+
+	evCodeSigning := len(option.Details.CsProvisioningMethods) > 0 && option.Details.ValidationType == domain.ValidationTypeEV
+	if !evCodeSigning {
+		if product.EvApprover != nil {
+			errors = append(errors, domain.ProductError{
+				AttributeName:  "evApprover",
+				AttributeValue: product.EvApprover.Email,
+			})
+		}
+		return errors
+	}
+
+	// extended validation code signing orders need an organization contact to approve the order
+	if product.EvApprover == nil || product.EvApprover.FirstName == "" || product.EvApprover.LastName == "" || product.EvApprover.Email == "" {
+		value := ""
+		if product.EvApprover != nil {
+			value = product.EvApprover.Email
+		}
+		errors = append(errors, domain.ProductError{
+			AttributeName:  "evApprover",
+			AttributeValue: value,
+		})
+	}
+	return errors
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -16,6 +17,276 @@ func TestGetOptions(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		testGetOptions(t)
 	})
+
+	t.Run("customFieldsForbidden", func(t *testing.T) {
+		productOptions := testGetOptionsLookupForbidden(t, getCustomFieldsUri)
+		require.Empty(t, productOptions[0].Details.CustomFields)
+		require.NotEmpty(t, productOptions[0].Details.Containers)
+	})
+
+	t.Run("containersForbidden", func(t *testing.T) {
+		productOptions := testGetOptionsLookupForbidden(t, getContainersUri)
+		require.Empty(t, productOptions[0].Details.Containers)
+		require.NotEmpty(t, productOptions[0].Details.CustomFields)
+	})
+
+	t.Run("productLimitsForbidden", func(t *testing.T) {
+		productOptions := testGetOptionsLookupForbidden(t, getProductLimitsUri)
+		require.Empty(t, productOptions[0].Details.Containers)
+	})
+
+	t.Run("serverPlatformsForbidden", func(t *testing.T) {
+		productOptions := testGetOptionsLookupForbidden(t, getServerPlatformsUri)
+		require.Empty(t, productOptions[0].Details.ServerPlatforms)
+		require.NotEmpty(t, productOptions[0].Details.Containers)
+	})
+}
+
+// TestValidateProduct ...
+func TestValidateProduct(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			AutoRenew:      true,
+			CustomFields:   []domain.CustomFieldValue{{ID: 10, Value: "42"}, {ID: 11, Value: "a@example.com, b@example.com"}},
+		}, nil)
+	})
+
+	t.Run("autoRenewNotAllowed", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			AutoRenew:            true,
+			CsProvisioningMethod: domain.CsProvisioningMethodClientApp,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "autoRenew",
+				AttributeValue: "true",
+			},
+		})
+	})
+
+	t.Run("validEvCodeSign", func(t *testing.T) {
+		testValidateProduct(t, "EV CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: domain.CsProvisioningMethodShipToken,
+			EvApprover: &domain.Contact{
+				FirstName: "Jane",
+				LastName:  "Doe",
+				Email:     "jane.doe@example.com",
+			},
+		}, nil)
+	})
+
+	t.Run("missingEvApprover", func(t *testing.T) {
+		testValidateProduct(t, "EV CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: domain.CsProvisioningMethodShipToken,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "evApprover",
+				AttributeValue: "",
+			},
+		})
+	})
+
+	t.Run("defaultProvisioningMethod", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+		}, nil)
+	})
+
+	t.Run("invalidProvisioningMethod", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: "carrier_pigeon",
+		}, []domain.ProductError{
+			{
+				AttributeName:  "csProvisioningMethod",
+				AttributeValue: "carrier_pigeon",
+			},
+		})
+	})
+
+	t.Run("evApproverOnNonEvCodeSign", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			EvApprover:     &domain.Contact{FirstName: "Jane", LastName: "Doe", Email: "jane.doe@example.com"},
+		}, []domain.ProductError{
+			{
+				AttributeName:  "evApprover",
+				AttributeValue: "jane.doe@example.com",
+			},
+		})
+	})
+
+	t.Run("evApproverOnSsl", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			EvApprover:     &domain.Contact{FirstName: "Jane", LastName: "Doe", Email: "jane.doe@example.com"},
+		}, []domain.ProductError{
+			{
+				AttributeName:  "evApprover",
+				AttributeValue: "jane.doe@example.com",
+			},
+		})
+	})
+
+	t.Run("provisioningMethodOnSsl", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: domain.CsProvisioningMethodClientApp,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "csProvisioningMethod",
+				AttributeValue: domain.CsProvisioningMethodClientApp,
+			},
+		})
+	})
+
+	t.Run("invalidDcvMethod", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			DcvMethod:      "fax",
+		}, []domain.ProductError{
+			{
+				AttributeName:  "dcvMethod",
+				AttributeValue: "fax",
+			},
+		})
+	})
+
+	t.Run("customFieldsUnavailable", func(t *testing.T) {
+		// the custom fields cannot be told unknown when they cannot be listed
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			CustomFields:   []domain.CustomFieldValue{{ID: 10, Value: "10"}, {ID: 42, Value: "unknown"}},
+		}, nil, getCustomFieldsUri)
+	})
+
+	t.Run("invalidCustomFields", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			CustomFields:   []domain.CustomFieldValue{{ID: 10, Value: "ten"}, {ID: 12, Value: "retired"}},
+		}, []domain.ProductError{
+			{
+				AttributeName:  "customFields[10]",
+				AttributeValue: "ten",
+			},
+			{
+				AttributeName:  "customFields[12]",
+				AttributeValue: "retired",
+			},
+		})
+	})
+
+	t.Run("validContainer", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			ContainerID:    5,
+		}, nil)
+	})
+
+	t.Run("containersUnavailable", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			ContainerID:    6,
+		}, nil, getContainersUri)
+	})
+
+	t.Run("productLimitsUnavailable", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			ContainerID:    5,
+		}, nil, getProductLimitsUri)
+	})
+
+	t.Run("organizationNotInContainer", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 1,
+			HashAlgorithm:  "sha256",
+			ContainerID:    6,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "organizationId",
+				AttributeValue: "1",
+			},
+		})
+	})
+
+	t.Run("productNotAllowedInContainer", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: domain.CsProvisioningMethodClientApp,
+			ContainerID:          6,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "containerId",
+				AttributeValue: "6",
+			},
+		})
+	})
+
+	t.Run("validServerPlatform", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID:   1,
+			HashAlgorithm:    "sha256",
+			ServerPlatformID: 14,
+		}, nil)
+	})
+
+	t.Run("serverPlatformsUnavailable", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID:   1,
+			HashAlgorithm:    "sha256",
+			ServerPlatformID: 99,
+		}, nil, getServerPlatformsUri)
+	})
+
+	t.Run("serverPlatformNotOffered", func(t *testing.T) {
+		testValidateProduct(t, "CodeSign Certificates", domain.Product{
+			OrganizationID:       1,
+			HashAlgorithm:        "sha256",
+			CsProvisioningMethod: domain.CsProvisioningMethodClientApp,
+			ServerPlatformID:     14,
+		}, []domain.ProductError{
+			{
+				AttributeName:  "serverPlatformId",
+				AttributeValue: "14",
+			},
+		})
+	})
+
+	t.Run("invalidAttributes", func(t *testing.T) {
+		testValidateProduct(t, "SSL Certificates", domain.Product{
+			OrganizationID: 2,
+			HashAlgorithm:  "md5",
+		}, []domain.ProductError{
+			{
+				AttributeName:  "hashAlgorithm",
+				AttributeValue: "md5",
+			},
+			{
+				AttributeName:  "organizationId",
+				AttributeValue: "2",
+			},
+		})
+	})
 }
 
 func testGetOptions(t *testing.T) {
@@ -34,21 +305,159 @@ func testGetOptions(t *testing.T) {
 	}
 	defer httpmock.DeactivateAndReset()
 
+	registerGetOptionsResponders()
+
+	productOptions, _, err := NewOptionsService().GetOptions(context.Background(), connection)
+	require.NoError(t, err)
+	require.Len(t, productOptions, 3)
+	// the organizations are listed once for all the containers
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["GET "+serverURL+getOrganizationsUri])
+	require.Equal(t, productOptions[0].Name, "SSL Certificates")
+	require.Equal(t, productOptions[0].Types, []domain.ProductType{domain.ProductTypeSsl})
+	require.Equal(t, productOptions[0].Details.NameID, "SSL Certificates ID")
+	require.Equal(t, productOptions[0].Details.Hashes, []string{"sha256", "sha512"})
+	require.Equal(t, productOptions[0].Details.DefaultHashAlgorithm, "sha256")
+	require.Equal(t, productOptions[0].Details.Organizations, []int{1, 3})
+	require.True(t, productOptions[0].Details.AllowAutoRenew)
+	require.Empty(t, productOptions[0].Details.CsProvisioningMethods)
+	require.Equal(t, productOptions[0].Details.DcvMethods, []string{"email", "dns-txt-token", "dns-cname-token", "http-token"})
+	require.Equal(t, productOptions[0].Details.CustomFields, []domain.CustomField{
+		{
+			ID:       10,
+			Label:    "Cost Center",
+			DataType: "int",
+			Pattern:  customFieldPatterns["int"],
+		},
+		{
+			ID:       11,
+			Label:    "Notify",
+			DataType: "email_list",
+			Pattern:  customFieldPatterns["email_list"],
+		},
+	})
+	require.Equal(t, productOptions[0].Details.Containers, []domain.Container{
+		{
+			ID:            5,
+			Name:          "Headquarters",
+			Organizations: []int{1},
+		},
+		{
+			ID:            6,
+			Name:          "Marketing",
+			Organizations: []int{3},
+		},
+	})
+	require.Equal(t, productOptions[0].Details.ServerPlatforms, []domain.ServerPlatform{
+		{
+			ID:   2,
+			Name: "Apache",
+		},
+		{
+			ID:   14,
+			Name: "Microsoft IIS 10",
+		},
+	})
+	require.Equal(t, productOptions[1].Name, "CodeSign Certificates")
+	require.Empty(t, productOptions[1].Details.ServerPlatforms)
+	require.Equal(t, productOptions[1].Details.Containers, []domain.Container{
+		{
+			ID:            5,
+			Name:          "Headquarters",
+			Organizations: []int{1},
+		},
+	})
+	require.Equal(t, productOptions[1].Types, []domain.ProductType{domain.ProductTypeCodeSign})
+	require.Equal(t, productOptions[1].Details.NameID, "CodeSign Certificates ID")
+	require.Equal(t, productOptions[1].Details.Hashes, []string{"sha256", "sha512"})
+	require.Equal(t, productOptions[1].Details.DefaultHashAlgorithm, "sha256")
+	require.Equal(t, productOptions[1].Details.Organizations, []int{1, 3})
+	require.False(t, productOptions[1].Details.AllowAutoRenew)
+	require.Equal(t, productOptions[1].Details.CsProvisioningMethods, []string{"client_app", "ship_token", "existing_token"})
+	require.Empty(t, productOptions[1].Details.DcvMethods)
+	require.Equal(t, productOptions[2].Name, "EV CodeSign Certificates")
+	require.Equal(t, productOptions[2].Types, []domain.ProductType{domain.ProductTypeCodeSign})
+	require.Equal(t, productOptions[2].Details.ValidationType, domain.ValidationTypeEV)
+	require.Equal(t, productOptions[2].Details.CsProvisioningMethods, []string{"client_app", "ship_token", "existing_token"})
+}
+
+// testGetOptionsLookupForbidden gets the options with an API key not allowed to call the given URI
+func testGetOptionsLookupForbidden(t *testing.T, uri string) []domain.ProductOption {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	registerGetOptionsResponders()
+	httpmock.RegisterResponder("GET", serverURL+uri,
+		httpmock.NewStringResponder(http.StatusForbidden, `{"errors":[{"code":"access_denied","message":"Permission denied."}]}`))
+
+	productOptions, importOptions, err := NewOptionsService().GetOptions(context.Background(), connection)
+	require.NoError(t, err)
+	require.Len(t, productOptions, 3)
+	require.Len(t, importOptions, 3)
+	return productOptions
+}
+
+// testValidateProduct validates the product, the API key not being allowed to call the forbidden URIs
+func testValidateProduct(t *testing.T, name string, product domain.Product, expectedErrors []domain.ProductError, forbidden ...string) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	registerGetOptionsResponders()
+	for _, uri := range forbidden {
+		httpmock.RegisterResponder("GET", serverURL+uri,
+			httpmock.NewStringResponder(http.StatusForbidden, `{"errors":[{"code":"access_denied","message":"Permission denied."}]}`))
+	}
+
+	productErrors, err := NewOptionsService().ValidateProduct(context.Background(), connection, name, product)
+	require.NoError(t, err)
+	require.Equal(t, expectedErrors, productErrors)
+}
+
+func registerGetOptionsResponders() {
 	httpmock.RegisterResponder("GET", serverURL+getOrganizationsUri,
 		func(req *http.Request) (*http.Response, error) {
 			return httpmock.NewJsonResponse(http.StatusOK, &getOrganizationsResponse{
 				Organizations: []organization{
 					{
-						ID:       1,
-						Name:     "Org 1",
-						Status:   "active",
-						IsActive: true,
+						ID:        1,
+						Name:      "Org 1",
+						Status:    "active",
+						IsActive:  true,
+						Container: &container{ID: 5, Name: "Headquarters"},
 					},
 					{
-						ID:       2,
-						Name:     "Org 2",
-						Status:   "inactive",
-						IsActive: false,
+						ID:        2,
+						Name:      "Org 2",
+						Status:    "inactive",
+						IsActive:  false,
+						Container: &container{ID: 5, Name: "Headquarters"},
+					},
+					{
+						ID:        3,
+						Name:      "Org 3",
+						Status:    "active",
+						IsActive:  true,
+						Container: &container{ID: 6, Name: "Marketing"},
 					},
 				},
 			})
@@ -63,6 +472,7 @@ func testGetOptions(t *testing.T) {
 						Name:            "SSL Certificates",
 						NameID:          "SSL Certificates ID",
 						CertificateType: "ssl_certificate",
+						AllowAutoRenew:  true,
 						Hashes: signatureHashTypes{
 							AllowedHashTypes: []hashType{
 								{
@@ -95,24 +505,115 @@ func testGetOptions(t *testing.T) {
 							DefaultHashType: "sha256",
 						},
 					},
+					{
+						Name:            "EV CodeSign Certificates",
+						NameID:          "EV CodeSign Certificates ID",
+						CertificateType: "code_signing_certificate",
+						ValidationType:  "ev",
+						Hashes: signatureHashTypes{
+							AllowedHashTypes: []hashType{
+								{
+									ID:   "sha256",
+									Name: "SHA-256",
+								},
+							},
+							DefaultHashType: "sha256",
+						},
+					},
+				},
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", serverURL+getCustomFieldsUri,
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, &getCustomFieldsResponse{
+				Metadata: []digicertCustomField{
+					{
+						ID:       10,
+						Label:    "Cost Center",
+						IsActive: true,
+						DataType: "int",
+					},
+					{
+						ID:       11,
+						Label:    "Notify",
+						IsActive: true,
+						DataType: "email_list",
+					},
+					{
+						ID:         12,
+						Label:      "Retired",
+						IsRequired: true,
+						IsActive:   false,
+						DataType:   "text",
+					},
+				},
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", serverURL+getContainersUri,
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, &getContainersResponse{
+				Containers: []container{
+					{
+						ID:       5,
+						Name:     "Headquarters",
+						IsActive: true,
+					},
+					{
+						ID:       6,
+						Name:     "Marketing",
+						ParentID: 5,
+						IsActive: true,
+					},
+					{
+						ID:       7,
+						Name:     "Closed",
+						ParentID: 5,
+						IsActive: false,
+					},
 				},
 			})
 		},
 	)
 
-	productOptions, _, err := NewOptionsService().GetOptions(connection)
-	require.NoError(t, err)
-	require.Len(t, productOptions, 2)
-	require.Equal(t, productOptions[0].Name, "SSL Certificates")
-	require.Equal(t, productOptions[0].Types, []domain.ProductType{domain.ProductTypeSsl})
-	require.Equal(t, productOptions[0].Details.NameID, "SSL Certificates ID")
-	require.Equal(t, productOptions[0].Details.Hashes, []string{"sha256", "sha512"})
-	require.Equal(t, productOptions[0].Details.DefaultHashAlgorithm, "sha256")
-	require.Equal(t, productOptions[0].Details.Organizations, []int{1})
-	require.Equal(t, productOptions[1].Name, "CodeSign Certificates")
-	require.Equal(t, productOptions[1].Types, []domain.ProductType{domain.ProductTypeCodeSign})
-	require.Equal(t, productOptions[1].Details.NameID, "CodeSign Certificates ID")
-	require.Equal(t, productOptions[1].Details.Hashes, []string{"sha256", "sha512"})
-	require.Equal(t, productOptions[1].Details.DefaultHashAlgorithm, "sha256")
-	require.Equal(t, productOptions[1].Details.Organizations, []int{1})
+	httpmock.RegisterResponder("GET", serverURL+getProductLimitsUri,
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, &getProductLimitsResponse{
+				Limits: []containerProductLimits{
+					{
+						ContainerID: 5,
+						ProductLimits: []productLimit{
+							{NameID: "SSL Certificates ID", IsAllowed: true},
+							{NameID: "CodeSign Certificates ID", IsAllowed: true},
+							{NameID: "EV CodeSign Certificates ID", IsAllowed: true},
+						},
+					},
+					{
+						ContainerID: 6,
+						ProductLimits: []productLimit{
+							{NameID: "SSL Certificates ID", IsAllowed: true},
+							{NameID: "CodeSign Certificates ID", IsAllowed: false},
+						},
+					},
+				},
+			})
+		},
+	)
+	httpmock.RegisterResponder("GET", serverURL+getServerPlatformsUri,
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(http.StatusOK, &getServerPlatformsResponse{
+				Platforms: []platform{
+					{
+						ID:   2,
+						Name: "Apache",
+					},
+					{
+						ID:   14,
+						Name: "Microsoft IIS 10",
+					},
+				},
+			})
+		},
+	)
 }
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+const (
+	getServerPlatformsUri = "/certificate/platforms"
+
+	// defaultServerPlatformID is sent when no server platform is chosen and lets DigiCert use its generic platform
+	defaultServerPlatformID = -1
+)
+
+type platform struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type getServerPlatformsResponse struct {
+	Platforms []platform `json:"platforms"`
+}
+
+// getServerPlatforms retrieves the server platforms an SSL order can be placed for
+func getServerPlatforms(ctx context.Context, connection domain.Connection) ([]domain.ServerPlatform, error) {
+	resp, err := executeRequest(ctx, connection, nil, getServerPlatformsUri, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	platformsResponse := getServerPlatformsResponse{}
+	err = json.Unmarshal(resp.Body(), &platformsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := make([]domain.ServerPlatform, 0)
+	for _, p := range platformsResponse.Platforms {
+		platforms = append(platforms, domain.ServerPlatform{
+			ID:   p.ID,
+			Name: p.Name,
+		})
+	}
+	return platforms, nil
+}
+
+// serverPlatformID returns the server platform to order the certificate for, defaulting to the generic platform
+func serverPlatformID(product domain.Product) int {
+	if product.ServerPlatformID == 0 {
+		return defaultServerPlatformID
+	}
+	return product.ServerPlatformID
+}
+
+// validateServerPlatform checks that the chosen server platform is offered for the product
+func validateServerPlatform(option domain.ProductOption, product domain.Product) []domain.ProductError {
+	if product.ServerPlatformID == 0 || product.ServerPlatformID == defaultServerPlatformID {
+		return nil
+	}
+	for _, p := range option.Details.ServerPlatforms {
+		if p.ID == product.ServerPlatformID {
+			return nil
+		}
+	}
+	return []domain.ProductError{
+		{
+			AttributeName:  "serverPlatformId",
+			AttributeValue: strconv.Itoa(product.ServerPlatformID),
+		},
+	}
+}
@@ -1,19 +1,78 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
+// digicertError is returned when DigiCert answers a request with an unexpected HTTP status
+type digicertError struct {
+	StatusCode int
+	Errors     []digicertErrorDetail `json:"errors"`
+	body       string
+}
+
+type digicertErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newDigicertError(statusCode int, body []byte) *digicertError {
+	e := &digicertError{
+		StatusCode: statusCode,
+		body:       string(body),
+	}
+	// the body is kept as the error message even when it does not hold DigiCert error details
+	_ = json.Unmarshal(body, e)
+	return e
+}
+
+func (e *digicertError) Error() string {
+	return e.body
+}
+
+// hasCode reports whether DigiCert returned one of the error codes
+func (e *digicertError) hasCode(codes ...string) bool {
+	for _, detail := range e.Errors {
+		for _, code := range codes {
+			if detail.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // NewRestClient is a function that creates a resty client, to allow mocking and intercepting of HTTP requests
 var NewRestClient = resty.New
 
-func executeRequest(connection domain.Connection, requestBody any, uriPath string, requestMethod string) (*resty.Response, error) {
-	request := NewRestClient().R().SetHeader("Content-Type", "application/json").SetHeader("X-DC-DEVKEY", connection.Credentials.ApiKey)
+// executeRequest sends a request to the DigiCert server on behalf of the webhook request of ctx, and logs and
+// traces it as part of that request
+func executeRequest(ctx context.Context, connection domain.Connection, requestBody any, uriPath string, requestMethod string) (*resty.Response, error) {
+	endpoint := endpointTemplate(uriPath)
+	ctx, span := tracing.Tracer().Start(ctx, "DigiCert "+requestMethod+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", requestMethod),
+			attribute.String("url.path", uriPath),
+		))
+	defer span.End()
+
+	request := NewRestClient().R().SetContext(ctx).SetHeader("Content-Type", "application/json").SetHeader("X-DC-DEVKEY", connection.Credentials.ApiKey)
+	start := time.Now()
 	var resp *resty.Response
 	var err error
 	switch requestMethod {
@@ -27,12 +86,35 @@ func executeRequest(connection domain.Connection, requestBody any, uriPath strin
 		return nil, fmt.Errorf("unsupported HTTP request method")
 	}
 
+	if request.Attempt > 1 {
+		digicertRetries.WithLabelValues(endpoint).Add(float64(request.Attempt - 1))
+	}
+
+	logger := logging.FromContext(ctx).With(
+		zap.String("method", requestMethod),
+		zap.String("path", uriPath),
+		zap.Duration("latency", time.Since(start)),
+	)
 	if err != nil {
+		digicertRequests.WithLabelValues(endpoint, statusClass(0)).Inc()
+		logger.Error("DigiCert request failed", zap.Error(err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "DigiCert request failed")
 		return nil, err
 	}
+	digicertRequests.WithLabelValues(endpoint, statusClass(resp.StatusCode())).Inc()
+	logger.Info("DigiCert request", zap.Int("status", resp.StatusCode()))
+	span.SetAttributes(tracing.Status.Int(resp.StatusCode()))
 
-	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated && resp.StatusCode() != http.StatusAccepted {
-		return nil, fmt.Errorf(string(resp.Body()))
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusCreated && resp.StatusCode() != http.StatusAccepted && resp.StatusCode() != http.StatusNoContent {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode()))
+		return nil, newDigicertError(resp.StatusCode(), resp.Body())
 	}
 	return resp, nil
 }
+
+// Retryable tells whether the request may succeed when sent again, the DigiCert server being overloaded
+// or unavailable
+func (e *digicertError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
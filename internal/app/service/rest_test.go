@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestExecuteRequestLogging ...
+func TestExecuteRequestLogging(t *testing.T) {
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", serverURL+"/user/me", httpmock.NewStringResponder(http.StatusOK, `{}`))
+	httpmock.RegisterResponder("GET", serverURL+"/order/certificate/7", httpmock.NewStringResponder(http.StatusServiceUnavailable, `{"errors":[]}`))
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	ctx := logging.WithLogger(context.Background(), zap.New(core).With(zap.String("requestId", "request-1")))
+	connection := buildConnection()
+
+	_, err := executeRequest(ctx, connection, nil, "/user/me", http.MethodGet)
+	require.NoError(t, err)
+	_, err = executeRequest(ctx, connection, nil, "/order/certificate/7", http.MethodGet)
+	require.Error(t, err)
+
+	var digicertErr *digicertError
+	require.ErrorAs(t, err, &digicertErr)
+	require.True(t, digicertErr.Retryable())
+
+	entries := logs.FilterMessage("DigiCert request").All()
+	require.Len(t, entries, 2)
+	for i, expected := range []struct {
+		path   string
+		status int64
+	}{
+		{path: "/user/me", status: http.StatusOK},
+		{path: "/order/certificate/7", status: http.StatusServiceUnavailable},
+	} {
+		fields := entries[i].ContextMap()
+		require.Equal(t, "request-1", fields["requestId"])
+		require.Equal(t, http.MethodGet, fields["method"])
+		require.Equal(t, expected.path, fields["path"])
+		require.Equal(t, expected.status, fields["status"])
+		require.Contains(t, fields, "latency")
+	}
+}
+
+// TestExecuteRequestTracing ...
+func TestExecuteRequestTracing(t *testing.T) {
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	savedProvider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(savedProvider)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	httpmock.RegisterResponder("GET", serverURL+"/order/certificate/7", httpmock.NewStringResponder(http.StatusOK, `{}`))
+	httpmock.RegisterResponder("GET", serverURL+"/order/certificate/8", httpmock.NewStringResponder(http.StatusNotFound, `{"errors":[]}`))
+
+	ctx, parent := tracing.Tracer().Start(context.Background(), "hook")
+	connection := buildConnection()
+	_, err := executeRequest(ctx, connection, nil, "/order/certificate/7", http.MethodGet)
+	require.NoError(t, err)
+	_, err = executeRequest(ctx, connection, nil, "/order/certificate/8", http.MethodGet)
+	require.Error(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	for i, expected := range []struct {
+		status     int64
+		statusCode codes.Code
+	}{
+		{status: http.StatusOK, statusCode: codes.Unset},
+		{status: http.StatusNotFound, statusCode: codes.Error},
+	} {
+		span := spans[i]
+		require.Equal(t, "DigiCert GET /order/certificate/{id}", span.Name)
+		require.Equal(t, trace.SpanKindClient, span.SpanKind)
+		require.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+		require.Equal(t, expected.statusCode, span.Status.Code)
+
+		attributes := map[string]int64{}
+		for _, attribute := range span.Attributes {
+			attributes[string(attribute.Key)] = attribute.Value.AsInt64()
+		}
+		require.Equal(t, expected.status, attributes[string(tracing.Status)])
+	}
+}
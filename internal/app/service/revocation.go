@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	orderDuplicatesUri        = "/order/certificate/%s/duplicate"
+	revokeOrderCertificateUri = "/order/certificate/%s/revoke"
+)
+
+type duplicateCertificate struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+type getDuplicatesResponse struct {
+	Certificates []duplicateCertificate `json:"certificates"`
+}
+
+type revocationRequestOrder struct {
+	ID int `json:"id"`
+}
+
+type revocationRequestCertificate struct {
+	ID int `json:"id"`
+}
+
+type digicertRevocationRequest struct {
+	ID          int                           `json:"id"`
+	Status      string                        `json:"status"`
+	Order       *revocationRequestOrder       `json:"order"`
+	Certificate *revocationRequestCertificate `json:"certificate"`
+}
+
+// CheckRevocation will check the outcome of a certificate revocation request submitted to a Certificate Authority
+func (cs *Certificate) CheckRevocation(ctx context.Context, connection domain.Connection, requestID string) (*domain.RevocationDetails, error) {
+	details := &domain.RevocationDetails{
+		RequestID: requestID,
+	}
+
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(requestUri, requestID), http.MethodGet)
+	if err != nil {
+		zap.L().Error("failed to retrieve revocation request from DigiCert CA", zap.String("requestId", requestID), zap.Error(err))
+		errMessage := fmt.Sprintf("failed to retrieve revocation request %s from DigiCert CA server: %s", requestID, err.Error())
+		details.Status = domain.RevocationStatusFailed
+		details.ErrorMessage = &errMessage
+		return details, nil
+	}
+
+	request := digicertRevocationRequest{}
+	err = json.Unmarshal(resp.Body(), &request)
+	if err != nil {
+		zap.L().Error("failed to unmarshal revocation request.", zap.Error(err))
+		errMessage := fmt.Sprintf("failed to retrieve revocation request %s from DigiCert CA server: %s", requestID, err.Error())
+		details.Status = domain.RevocationStatusFailed
+		details.ErrorMessage = &errMessage
+		return details, nil
+	}
+
+	switch request.Status {
+	case "approved":
+		details.Status = domain.RevocationStatusRevoked
+		// an approved request is only final once DigiCert has revoked the certificate, which may be a duplicate
+		// of an order that stays issued
+		if request.Order != nil && request.Order.ID > 0 && request.Certificate != nil && request.Certificate.ID > 0 {
+			if revoked, ok := certificateRevoked(ctx, connection, request.Order.ID, request.Certificate.ID); ok && !revoked {
+				errMessage := fmt.Sprintf("revocation request %s is approved, waiting for the certificate to be revoked", requestID)
+				details.Status = domain.RevocationStatusPending
+				details.ErrorMessage = &errMessage
+			}
+		}
+	case "rejected":
+		errMessage := fmt.Sprintf("revocation request %s has been rejected", requestID)
+		details.Status = domain.RevocationStatusRejected
+		details.ErrorMessage = &errMessage
+	case "submitted", "pending":
+		details.Status = domain.RevocationStatusPending
+	default:
+		errMessage := fmt.Sprintf("unexpected status of revocation request %s: %s", requestID, request.Status)
+		details.Status = domain.RevocationStatusFailed
+		details.ErrorMessage = &errMessage
+	}
+	return details, nil
+}
+
+// certificateRevoked reports whether a certificate of the order has been revoked, from the order status for the
+// certificate of the order and from the duplicates otherwise. ok is false when the status could not be retrieved
+func certificateRevoked(ctx context.Context, connection domain.Connection, orderID int, certificateID int) (revoked bool, ok bool) {
+	order := strconv.Itoa(orderID)
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(orderCertificateUri, order), http.MethodGet)
+	if err != nil {
+		zap.L().Warn("failed to retrieve order to cross-check revocation", zap.Int("orderId", orderID), zap.Error(err))
+		return false, false
+	}
+	orderDetails := digiCertOrderDetails{}
+	if err = json.Unmarshal(resp.Body(), &orderDetails); err != nil {
+		zap.L().Warn("failed to unmarshal order to cross-check revocation", zap.Int("orderId", orderID), zap.Error(err))
+		return false, false
+	}
+	if orderDetails.Certificate != nil && orderDetails.Certificate.ID == certificateID {
+		return orderDetails.Status == "revoked", true
+	}
+
+	resp, err = executeRequest(ctx, connection, nil, fmt.Sprintf(orderDuplicatesUri, order), http.MethodGet)
+	if err != nil {
+		zap.L().Warn("failed to retrieve duplicates to cross-check revocation", zap.Int("orderId", orderID), zap.Error(err))
+		return false, false
+	}
+	duplicates := getDuplicatesResponse{}
+	if err = json.Unmarshal(resp.Body(), &duplicates); err != nil {
+		zap.L().Warn("failed to unmarshal duplicates to cross-check revocation", zap.Int("orderId", orderID), zap.Error(err))
+		return false, false
+	}
+	for _, duplicate := range duplicates.Certificates {
+		if duplicate.ID == certificateID {
+			return duplicate.Status == "revoked", true
+		}
+	}
+	zap.L().Warn("certificate to cross-check revocation not found on order", zap.Int("orderId", orderID), zap.Int("certificateId", certificateID))
+	return false, false
+}
+
+// RevokeOrder will submit revocation requests for the certificate of an order and all of its duplicates. The
+// certificate of the order is revoked through the order, and each duplicate through its own certificate. The
+// requests are submitted one certificate at a time and are not atomic: when some fail, the others stay submitted
+// and the certificates that failed are left unrevoked although they share the compromised key, which the FAILED
+// status and the failed certificate IDs report for the revocation to be retried. Certificates already revoked are
+// skipped, so a retry only submits the ones still issued
+func (cs *Certificate) RevokeOrder(ctx context.Context, connection domain.Connection, orderID string, reasonCode int) (*domain.RevocationDetails, error) {
+	failed := func(err error) (*domain.RevocationDetails, error) {
+		zap.L().Error("failed to retrieve certificates of order to revoke", zap.String("orderId", orderID), zap.Error(err))
+		errMessage := fmt.Sprintf("failed to retrieve certificates of order %s from DigiCert CA server: %s", orderID, err.Error())
+		return &domain.RevocationDetails{
+			Status:       domain.RevocationStatusFailed,
+			ErrorMessage: &errMessage,
+		}, nil
+	}
+
+	resp, err := executeRequest(ctx, connection, nil, fmt.Sprintf(orderCertificateUri, orderID), http.MethodGet)
+	if err != nil {
+		return failed(err)
+	}
+	order := digiCertOrderDetails{}
+	if err = json.Unmarshal(resp.Body(), &order); err != nil {
+		return failed(err)
+	}
+
+	// every duplicate shares the compromised key, so nothing is revoked unless all of them are known
+	resp, err = executeRequest(ctx, connection, nil, fmt.Sprintf(orderDuplicatesUri, orderID), http.MethodGet)
+	if err != nil {
+		return failed(err)
+	}
+	duplicates := getDuplicatesResponse{}
+	if err = json.Unmarshal(resp.Body(), &duplicates); err != nil {
+		return failed(err)
+	}
+
+	var certificates []duplicateCertificate
+	if order.Certificate != nil && order.Certificate.ID > 0 {
+		certificates = append(certificates, duplicateCertificate{
+			ID:     order.Certificate.ID,
+			Status: order.Status,
+		})
+	}
+	certificates = append(certificates, duplicates.Certificates...)
+
+	details := &domain.RevocationDetails{
+		Status: domain.RevocationStatusSubmitted,
+	}
+	requestBody := newRevokeCertificateRequestBody{
+		Reason:  revocationReasonCodeToString(reasonCode),
+		Comment: "",
+	}
+	for _, certificate := range certificates {
+		id := strconv.Itoa(certificate.ID)
+		if certificate.Status == "revoked" {
+			details.RevokedCertificateIDs = append(details.RevokedCertificateIDs, id)
+			continue
+		}
+		uriPath := fmt.Sprintf(revokeCertificateUri, id)
+		if order.Certificate != nil && certificate.ID == order.Certificate.ID {
+			uriPath = fmt.Sprintf(revokeOrderCertificateUri, orderID)
+		}
+		requestID, err := submitRevocation(ctx, connection, uriPath, requestBody)
+		if err != nil {
+			zap.L().Error("failed to submit certificate revocation request to DigiCert CA", zap.String("orderId", orderID), zap.String("certificateId", id), zap.Error(err))
+			details.FailedCertificateIDs = append(details.FailedCertificateIDs, id)
+			continue
+		}
+		details.SubmittedCertificateIDs = append(details.SubmittedCertificateIDs, id)
+		details.RequestIDs = append(details.RequestIDs, strconv.Itoa(requestID))
+	}
+
+	if len(certificates) == 0 {
+		errMessage := fmt.Sprintf("no certificate found on order %s", orderID)
+		details.Status = domain.RevocationStatusFailed
+		details.ErrorMessage = &errMessage
+	} else if len(details.FailedCertificateIDs) > 0 {
+		errMessage := fmt.Sprintf("failed to submit revocation requests to DigiCert CA server for certificates %s of order %s", strings.Join(details.FailedCertificateIDs, ", "), orderID)
+		details.Status = domain.RevocationStatusFailed
+		details.ErrorMessage = &errMessage
+	}
+	return details, nil
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+// TestRevokeCertificate ...
+func TestRevokeCertificate(t *testing.T) {
+	connection := buildConnection()
+
+	// override the resty constructor to intercept HTTPS traffic
+	savedRestCtor := NewRestClient
+	defer func() { NewRestClient = savedRestCtor }()
+	NewRestClient = func() *resty.Client {
+		client := resty.New()
+		httpmock.ActivateNonDefault(client.GetClient())
+		return client
+	}
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("PUT", serverURL+fmt.Sprintf(revokeCertificateUri, "0A1B"),
+		httpmock.NewJsonResponderOrPanic(http.StatusCreated, &digicertRevokeCertificateResponse{
+			ID:     42,
+			Status: "submitted",
+		}),
+	)
+
+	details, err := NewCertificateService().RevokeCertificate(context.Background(), connection, "0A1B", 1)
+	require.NoError(t, err)
+	require.Equal(t, domain.RevocationStatusSubmitted, details.Status)
+	require.Equal(t, "42", details.RequestID)
+}
+
+// TestCheckRevocation ...
+func TestCheckRevocation(t *testing.T) {
+	tests := []struct {
+		name           string
+		httpStatus     int
+		request        *digicertRevocationRequest
+		orderStatus    string
+		duplicates     []duplicateCertificate
+		expectedStatus domain.RevocationStatus
+		expectedError  string
+	}{
+		{
+			name:           "submitted",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "submitted"},
+			expectedStatus: domain.RevocationStatusPending,
+		},
+		{
+			name:           "pending",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "pending"},
+			expectedStatus: domain.RevocationStatusPending,
+		},
+		{
+			name:           "revoked",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "approved", Order: &revocationRequestOrder{ID: 7}, Certificate: &revocationRequestCertificate{ID: 100}},
+			orderStatus:    "revoked",
+			expectedStatus: domain.RevocationStatusRevoked,
+		},
+		{
+			name:           "approvedNotYetRevoked",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "approved", Order: &revocationRequestOrder{ID: 7}, Certificate: &revocationRequestCertificate{ID: 100}},
+			orderStatus:    "issued",
+			expectedStatus: domain.RevocationStatusPending,
+			expectedError:  "revocation request 42 is approved, waiting for the certificate to be revoked",
+		},
+		{
+			name:           "duplicateRevoked",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "approved", Order: &revocationRequestOrder{ID: 7}, Certificate: &revocationRequestCertificate{ID: 101}},
+			orderStatus:    "issued",
+			duplicates:     []duplicateCertificate{{ID: 101, Status: "revoked"}},
+			expectedStatus: domain.RevocationStatusRevoked,
+		},
+		{
+			name:           "approvedDuplicateNotYetRevoked",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "approved", Order: &revocationRequestOrder{ID: 7}, Certificate: &revocationRequestCertificate{ID: 101}},
+			orderStatus:    "issued",
+			duplicates:     []duplicateCertificate{{ID: 101, Status: "issued"}},
+			expectedStatus: domain.RevocationStatusPending,
+			expectedError:  "revocation request 42 is approved, waiting for the certificate to be revoked",
+		},
+		{
+			name:           "approvedOrderUnavailable",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "approved", Order: &revocationRequestOrder{ID: 7}, Certificate: &revocationRequestCertificate{ID: 100}},
+			expectedStatus: domain.RevocationStatusRevoked,
+		},
+		{
+			name:           "rejected",
+			httpStatus:     http.StatusOK,
+			request:        &digicertRevocationRequest{ID: 42, Status: "rejected"},
+			expectedStatus: domain.RevocationStatusRejected,
+			expectedError:  "revocation request 42 has been rejected",
+		},
+		{
+			name:           "notFound",
+			httpStatus:     http.StatusNotFound,
+			expectedStatus: domain.RevocationStatusFailed,
+			expectedError:  "failed to retrieve revocation request 42 from DigiCert CA server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := buildConnection()
+
+			// override the resty constructor to intercept HTTPS traffic
+			savedRestCtor := NewRestClient
+			defer func() { NewRestClient = savedRestCtor }()
+			NewRestClient = func() *resty.Client {
+				client := resty.New()
+				httpmock.ActivateNonDefault(client.GetClient())
+				return client
+			}
+			defer httpmock.DeactivateAndReset()
+
+			httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(requestUri, "42"),
+				func(req *http.Request) (*http.Response, error) {
+					if tt.request == nil {
+						return httpmock.NewStringResponse(tt.httpStatus, `{"errors":[{"code":"not_found","message":"Request not found."}]}`), nil
+					}
+					return httpmock.NewJsonResponse(tt.httpStatus, tt.request)
+				},
+			)
+			if tt.orderStatus != "" {
+				httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderCertificateUri, strconv.Itoa(7)),
+					httpmock.NewJsonResponderOrPanic(http.StatusOK, &digiCertOrderDetails{
+						ID:          7,
+						Status:      tt.orderStatus,
+						Certificate: &orderCertificate{ID: 100},
+					}),
+				)
+				httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderDuplicatesUri, "7"),
+					httpmock.NewJsonResponderOrPanic(http.StatusOK, &getDuplicatesResponse{Certificates: tt.duplicates}),
+				)
+			}
+
+			details, err := NewCertificateService().CheckRevocation(context.Background(), connection, "42")
+			require.NoError(t, err)
+			require.Equal(t, "42", details.RequestID)
+			require.Equal(t, tt.expectedStatus, details.Status)
+			if tt.expectedError == "" {
+				require.Nil(t, details.ErrorMessage)
+			} else {
+				require.NotNil(t, details.ErrorMessage)
+				require.Contains(t, *details.ErrorMessage, tt.expectedError)
+			}
+		})
+	}
+}
+
+// TestRevokeOrder ...
+func TestRevokeOrder(t *testing.T) {
+	tests := []struct {
+		name              string
+		orderStatus       int
+		duplicates        []duplicateCertificate
+		failing           string
+		expectedStatus    domain.RevocationStatus
+		expectedSubmitted []string
+		expectedRevoked   []string
+		expectedFailed    []string
+		expectedError     string
+	}{
+		{
+			name:        "allSubmitted",
+			orderStatus: http.StatusOK,
+			duplicates: []duplicateCertificate{
+				{ID: 101, Status: "issued"},
+				{ID: 102, Status: "revoked"},
+			},
+			expectedStatus:    domain.RevocationStatusSubmitted,
+			expectedSubmitted: []string{"100", "101"},
+			expectedRevoked:   []string{"102"},
+		},
+		{
+			name:        "duplicateFailed",
+			orderStatus: http.StatusOK,
+			duplicates: []duplicateCertificate{
+				{ID: 101, Status: "issued"},
+			},
+			failing:           "101",
+			expectedStatus:    domain.RevocationStatusFailed,
+			expectedSubmitted: []string{"100"},
+			expectedFailed:    []string{"101"},
+			expectedError:     "failed to submit revocation requests to DigiCert CA server for certificates 101 of order 7",
+		},
+		{
+			name:        "orderCertificateFailed",
+			orderStatus: http.StatusOK,
+			duplicates: []duplicateCertificate{
+				{ID: 101, Status: "issued"},
+				{ID: 102, Status: "revoked"},
+			},
+			failing:           "100",
+			expectedStatus:    domain.RevocationStatusFailed,
+			expectedSubmitted: []string{"101"},
+			expectedRevoked:   []string{"102"},
+			expectedFailed:    []string{"100"},
+			expectedError:     "failed to submit revocation requests to DigiCert CA server for certificates 100 of order 7",
+		},
+		{
+			name:           "orderNotFound",
+			orderStatus:    http.StatusNotFound,
+			expectedStatus: domain.RevocationStatusFailed,
+			expectedError:  "failed to retrieve certificates of order 7 from DigiCert CA server",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection := buildConnection()
+
+			// override the resty constructor to intercept HTTPS traffic
+			savedRestCtor := NewRestClient
+			defer func() { NewRestClient = savedRestCtor }()
+			NewRestClient = func() *resty.Client {
+				client := resty.New()
+				httpmock.ActivateNonDefault(client.GetClient())
+				return client
+			}
+			defer httpmock.DeactivateAndReset()
+
+			if tt.orderStatus == http.StatusOK {
+				httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderCertificateUri, "7"),
+					httpmock.NewJsonResponderOrPanic(http.StatusOK, &digiCertOrderDetails{
+						ID:          7,
+						Status:      "issued",
+						Certificate: &orderCertificate{ID: 100},
+					}),
+				)
+			} else {
+				httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderCertificateUri, "7"),
+					httpmock.NewStringResponder(tt.orderStatus, `{"errors":[{"code":"not_found","message":"Order not found."}]}`),
+				)
+			}
+			httpmock.RegisterResponder("GET", serverURL+fmt.Sprintf(orderDuplicatesUri, "7"),
+				httpmock.NewJsonResponderOrPanic(http.StatusOK, &getDuplicatesResponse{Certificates: tt.duplicates}),
+			)
+			// the certificate of the order is revoked through the order, the duplicates through their certificate
+			revokeUris := map[string]string{
+				"100": fmt.Sprintf(revokeOrderCertificateUri, "7"),
+				"101": fmt.Sprintf(revokeCertificateUri, "101"),
+				"102": fmt.Sprintf(revokeCertificateUri, "102"),
+			}
+			for id, uri := range revokeUris {
+				if id == tt.failing {
+					httpmock.RegisterResponder("PUT", serverURL+uri,
+						httpmock.NewStringResponder(http.StatusBadRequest, `{"errors":[{"code":"invalid_status","message":"Certificate cannot be revoked."}]}`),
+					)
+					continue
+				}
+				httpmock.RegisterResponder("PUT", serverURL+uri,
+					httpmock.NewJsonResponderOrPanic(http.StatusCreated, &digicertRevokeCertificateResponse{ID: 42, Status: "submitted"}),
+				)
+			}
+
+			details, err := NewCertificateService().RevokeOrder(context.Background(), connection, "7", 1)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedStatus, details.Status)
+			require.Equal(t, tt.expectedSubmitted, details.SubmittedCertificateIDs)
+			require.Equal(t, tt.expectedRevoked, details.RevokedCertificateIDs)
+			require.Equal(t, tt.expectedFailed, details.FailedCertificateIDs)
+			if tt.expectedError == "" {
+				require.Nil(t, details.ErrorMessage)
+			} else {
+				require.NotNil(t, details.ErrorMessage)
+				require.Contains(t, *details.ErrorMessage, tt.expectedError)
+			}
+			require.Equal(t, 0, httpmock.GetCallCountInfo()["PUT "+serverURL+fmt.Sprintf(revokeCertificateUri, "102")])
+			require.Equal(t, 0, httpmock.GetCallCountInfo()["PUT "+serverURL+fmt.Sprintf(revokeCertificateUri, "100")])
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package tracing sets up the OpenTelemetry tracer provider and names the span attributes of the connector
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Span exporters
+const (
+	// ExporterNone turns tracing off, which is the default
+	ExporterNone = "none"
+	// ExporterOTLP sends the spans to an OTLP collector over HTTP
+	ExporterOTLP = "otlp"
+)
+
+// ServiceName names the connector in the traces
+const ServiceName = "digicert-ca-connector"
+
+const instrumentationName = "github.com/venafi/digicert-ca-connector"
+
+// Span attributes set by the connector
+const (
+	ProductNameID = attribute.Key("digicert.product_name_id")
+	OrderID       = attribute.Key("digicert.order_id")
+	OrderStatus   = attribute.Key("digicert.order_status")
+	// Status is the HTTP status DigiCert answered a request with
+	Status = attribute.Key("digicert.status")
+)
+
+// Config selects where the spans go. The OTLP exporter also honours the standard OTEL_EXPORTER_OTLP_*
+// environment variables, Endpoint takes precedence over them when set
+type Config struct {
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+	Endpoint string `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	Insecure bool   `yaml:"insecure" env:"TRACING_OTLP_INSECURE"`
+	// Headers are sent with the spans, typically to authenticate with the collector
+	Headers map[string]string `yaml:"headers" env:"TRACING_OTLP_HEADERS" secret:"true"`
+}
+
+// Tracer returns the tracer of the connector from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// SetAttributes adds the attributes to the span ctx belongs to, if any
+func SetAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attributes...)
+}
+
+// Configure installs the global tracer provider for the configured exporter, and the W3C trace context
+// propagator. The spans still buffered are flushed when the application stops
+func Configure(lifecycle fx.Lifecycle, config Config) error {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	switch config.Exporter {
+	case "", ExporterNone:
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return nil
+	case ExporterOTLP:
+	default:
+		return fmt.Errorf("unsupported tracing exporter %q", config.Exporter)
+	}
+
+	var opts []otlptracehttp.Option
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(config.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("tracing exporter not created: %w", err)
+	}
+	provider := NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	zap.L().Info("tracing enabled", zap.String("exporter", config.Exporter), zap.String("endpoint", config.Endpoint))
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// NewTracerProvider creates a tracer provider for the connector service sending its spans as the options say
+func NewTracerProvider(opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	}, opts...)...)
+}
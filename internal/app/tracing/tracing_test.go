@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestConfigure(t *testing.T) {
+	savedProvider, savedPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(savedProvider)
+		otel.SetTextMapPropagator(savedPropagator)
+	})
+
+	t.Run("off by default", func(t *testing.T) {
+		require.NoError(t, Configure(fxtest.NewLifecycle(t), Config{}))
+		require.IsType(t, noop.TracerProvider{}, otel.GetTracerProvider())
+		require.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+	})
+
+	t.Run("otlp", func(t *testing.T) {
+		lifecycle := fxtest.NewLifecycle(t)
+		require.NoError(t, Configure(lifecycle, Config{Exporter: ExporterOTLP, Endpoint: "localhost:4318", Insecure: true}))
+		require.NotEqual(t, noop.TracerProvider{}, otel.GetTracerProvider())
+		lifecycle.RequireStart().RequireStop()
+	})
+
+	t.Run("unsupported exporter", func(t *testing.T) {
+		require.EqualError(t, Configure(fxtest.NewLifecycle(t), Config{Exporter: "jaeger"}), `unsupported tracing exporter "jaeger"`)
+	})
+}
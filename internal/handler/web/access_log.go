@@ -0,0 +1,76 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.uber.org/zap"
+)
+
+type accessLogEntryKey struct{}
+
+// accessLogEntry collects the details of the request that are only known inside the /v1 group
+type accessLogEntry struct {
+	request string
+}
+
+// requestLogger gives every request an ID, taken from the X-Request-Id header when the caller sent one, and
+// a logger tagged with it. Once the request is handled, it logs one access line for it
+func requestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		logger := zap.L().With(zap.String("requestId", correlationID(c)))
+		entry := &accessLogEntry{}
+		ctx := logging.WithLogger(c.Request().Context(), logger)
+		ctx = context.WithValue(ctx, accessLogEntryKey{}, entry)
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		req := c.Request()
+		res := c.Response()
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("path", req.URL.Path),
+			zap.Int("status", res.Status),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("remoteIp", c.RealIP()),
+			zap.Int64("bytesIn", req.ContentLength),
+			zap.Int64("bytesOut", res.Size),
+		}
+		if entry.request != "" {
+			fields = append(fields, zap.String("request", entry.request))
+		}
+		logger.Info("access", fields...)
+		return nil
+	}
+}
+
+// addRequestCaptureMiddleware adds the plaintext payload, with API keys and CSRs redacted, to the access
+// log line when debugging
+func addRequestCaptureMiddleware(g *echo.Group) {
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			entry, _ := req.Context().Value(accessLogEntryKey{}).(*accessLogEntry)
+			if entry == nil || !logging.FromContext(req.Context()).Core().Enabled(zap.DebugLevel) {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return invalidPayloadError(fmt.Errorf("failed to read payload: %w", err))
+			}
+			entry.request = logging.RedactJSON(body)
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			return next(c)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// loggingWebhookService logs through the request logger, as the connector handlers do
+type loggingWebhookService struct {
+	echoWebhookService
+}
+
+func (s loggingWebhookService) HandleRequestCertificate(c echo.Context) error {
+	logging.FromContext(c.Request().Context()).Info("requesting certificate")
+	return s.echo(c)
+}
+
+func observeLogs(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	core, logs := observer.New(level)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+	return logs
+}
+
+func TestRequestLogger(t *testing.T) {
+	e := newEcho()
+	config := PayloadEncryptionConfig{KeyPath: filepath.Join(t.TempDir(), "missing.pem"), AllowPlaintext: true}
+	require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, loggingWebhookService{}, config))
+
+	t.Run("request ID and redacted payload", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.DebugLevel)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/requestcertificate", strings.NewReader(
+			`{"connection":{"credentials":{"apiKey":"secret-key"}},"pkcs10Request":"-----BEGIN CERTIFICATE REQUEST-----"}`))
+		req.Header.Set(echo.HeaderXRequestID, "request-1")
+		recorder := httptest.NewRecorder()
+		e.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "request-1", recorder.Header().Get(echo.HeaderXRequestID))
+
+		handlerLogs := logs.FilterMessage("requesting certificate").All()
+		require.Len(t, handlerLogs, 1)
+		require.Equal(t, "request-1", handlerLogs[0].ContextMap()["requestId"])
+
+		accessLogs := logs.FilterMessage("access").All()
+		require.Len(t, accessLogs, 1)
+		fields := accessLogs[0].ContextMap()
+		require.Equal(t, "request-1", fields["requestId"])
+		require.Equal(t, http.MethodPost, fields["method"])
+		require.Equal(t, "/v1/requestcertificate", fields["path"])
+		require.Equal(t, int64(http.StatusOK), fields["status"])
+		require.Contains(t, fields, "latency")
+		require.Equal(t, `{"connection":{"credentials":{"apiKey":"[REDACTED]"}},"pkcs10Request":"[REDACTED]"}`, fields["request"])
+
+		for _, entry := range logs.All() {
+			for _, value := range entry.ContextMap() {
+				if s, ok := value.(string); ok {
+					require.NotContains(t, s, "secret-key")
+				}
+			}
+		}
+	})
+
+	t.Run("generated request ID on error", func(t *testing.T) {
+		logs := observeLogs(t, zapcore.InfoLevel)
+
+		recorder := serve(e, http.MethodGet, "/v1/unknown", "")
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+		requestID := recorder.Header().Get(echo.HeaderXRequestID)
+		require.NotEmpty(t, requestID)
+
+		accessLogs := logs.FilterMessage("access").All()
+		require.Len(t, accessLogs, 1)
+		fields := accessLogs[0].ContextMap()
+		require.Equal(t, requestID, fields["requestId"])
+		require.Equal(t, int64(http.StatusNotFound), fields["status"])
+		require.NotContains(t, fields, "request")
+	})
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// HandleError renders every error returned by the handlers and middleware as a domain.ErrorResponse
+func HandleError(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, response := errorResponse(err)
+	response.CorrelationID = correlationID(c)
+	logging.FromContext(c.Request().Context()).Error("request failed", zap.String("path", c.Path()), zap.Int("status", status),
+		zap.String("code", string(response.Code)), zap.String("correlationId", response.CorrelationID), zap.Error(err))
+	trace.SpanFromContext(c.Request().Context()).RecordError(err)
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response)
+	}
+	if err != nil {
+		zap.L().Error("failed to write error response", zap.Error(err))
+	}
+}
+
+func errorResponse(err error) (int, domain.ErrorResponse) {
+	var opErr *domain.OperationError
+	if errors.As(err, &opErr) {
+		return opErr.Status, domain.ErrorResponse{
+			Code:      opErr.Code,
+			Message:   opErr.Message,
+			Retryable: opErr.Retryable,
+		}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		response := domain.ErrorResponse{
+			Code:      domain.ErrorCodeInvalidRequest,
+			Message:   fmt.Sprintf("%v", httpErr.Message),
+			Retryable: httpErr.Code >= http.StatusInternalServerError,
+		}
+		switch {
+		case httpErr.Code == http.StatusNotFound:
+			response.Code = domain.ErrorCodeNotFound
+		case httpErr.Code == http.StatusMethodNotAllowed:
+			response.Code = domain.ErrorCodeMethodNotAllowed
+		case httpErr.Code >= http.StatusInternalServerError:
+			response.Code = domain.ErrorCodeInternal
+		}
+		return httpErr.Code, response
+	}
+
+	// the details of unexpected errors stay in the logs
+	return http.StatusInternalServerError, domain.ErrorResponse{
+		Code:      domain.ErrorCodeInternal,
+		Message:   http.StatusText(http.StatusInternalServerError),
+		Retryable: true,
+	}
+}
+
+// correlationID returns the ID of the request, creating one when the caller did not send any
+func correlationID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	id := c.Request().Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	c.Response().Header().Set(echo.HeaderXRequestID, id)
+	return id
+}
+
+// invalidPayloadError fails a request whose payload cannot be decrypted
+func invalidPayloadError(err error) error {
+	return &domain.OperationError{
+		Status:  http.StatusBadRequest,
+		Code:    domain.ErrorCodeInvalidPayload,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
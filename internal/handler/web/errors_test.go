@@ -0,0 +1,78 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+)
+
+func TestHandleError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		requestID      string
+		expectedStatus int
+		expected       domain.ErrorResponse
+	}{
+		{
+			name:           "operationError",
+			err:            &domain.OperationError{Status: http.StatusBadRequest, Code: domain.ErrorCodeCAUnavailable, Message: "DigiCert unavailable", Retryable: true},
+			requestID:      "request-1",
+			expectedStatus: http.StatusBadRequest,
+			expected:       domain.ErrorResponse{Code: domain.ErrorCodeCAUnavailable, Message: "DigiCert unavailable", Retryable: true, CorrelationID: "request-1"},
+		},
+		{
+			name:           "notFound",
+			err:            echo.ErrNotFound,
+			requestID:      "request-2",
+			expectedStatus: http.StatusNotFound,
+			expected:       domain.ErrorResponse{Code: domain.ErrorCodeNotFound, Message: "Not Found", CorrelationID: "request-2"},
+		},
+		{
+			name:           "methodNotAllowed",
+			err:            echo.ErrMethodNotAllowed,
+			requestID:      "request-3",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expected:       domain.ErrorResponse{Code: domain.ErrorCodeMethodNotAllowed, Message: "Method Not Allowed", CorrelationID: "request-3"},
+		},
+		{
+			name:           "unexpected",
+			err:            errors.New("secret details"),
+			requestID:      "request-4",
+			expectedStatus: http.StatusInternalServerError,
+			expected:       domain.ErrorResponse{Code: domain.ErrorCodeInternal, Message: "Internal Server Error", Retryable: true, CorrelationID: "request-4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/checkorder", nil)
+			req.Header.Set(echo.HeaderXRequestID, tt.requestID)
+			recorder := httptest.NewRecorder()
+
+			HandleError(tt.err, echo.New().NewContext(req, recorder))
+			require.Equal(t, tt.expectedStatus, recorder.Code)
+			require.Equal(t, tt.requestID, recorder.Header().Get(echo.HeaderXRequestID))
+
+			response := domain.ErrorResponse{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			require.Equal(t, tt.expected, response)
+		})
+	}
+
+	t.Run("generated correlation ID", func(t *testing.T) {
+		recorder := serve(newEcho(), http.MethodGet, "/v1/unknown", "")
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+
+		response := domain.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Len(t, response.CorrelationID, 32)
+		require.Equal(t, response.CorrelationID, recorder.Header().Get(echo.HeaderXRequestID))
+	})
+}
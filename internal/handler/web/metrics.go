@@ -0,0 +1,104 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var (
+	hookRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hook_requests_total",
+		Help: "Number of webhook requests, by hook and status class.",
+	}, []string{"hook", "status"})
+
+	hookRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hook_request_duration_seconds",
+		Help:    "Time taken to handle the webhook requests, by hook.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"hook"})
+
+	hookRetryableErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hook_retryable_errors_total",
+		Help: "Number of webhook requests failed with an error the platform retries, by hook.",
+	}, []string{"hook"})
+
+	payloadKeyReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payload_encryption_key_reloads_total",
+		Help: "Number of payload encryption key reloads, by result.",
+	}, []string{"result"})
+
+	payloadDecryptionFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "payload_decryption_failures_total",
+		Help: "Number of payloads that could not be decrypted, by reason.",
+	}, []string{"reason"})
+)
+
+// Payload decryption failure reasons
+const (
+	decryptionFailureRead                 = "read"
+	decryptionFailureInvalidJWE           = "invalid_jwe"
+	decryptionFailureUnsupportedAlgorithm = "unsupported_algorithm"
+	decryptionFailureNoMatchingKey        = "no_matching_key"
+)
+
+// addHookMetricsMiddleware counts and times the webhook requests. It renders the errors itself, so that
+// their status is known, and comes after the response encryption for the errors to be encrypted still
+func addHookMetricsMiddleware(g *echo.Group) {
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				if status, response := errorResponse(err); response.Retryable || status >= http.StatusInternalServerError {
+					hookRetryableErrors.WithLabelValues(hookName(c)).Inc()
+				}
+				c.Error(err)
+			}
+
+			hook := hookName(c)
+			hookRequests.WithLabelValues(hook, strconv.Itoa(c.Response().Status/100)+"xx").Inc()
+			hookRequestDuration.WithLabelValues(hook).Observe(time.Since(start).Seconds())
+			return nil
+		}
+	})
+}
+
+// hookName is the route of the request within the /v1 group, which is bounded unlike the request path
+func hookName(c echo.Context) string {
+	return strings.TrimPrefix(c.Path(), "/v1/")
+}
+
+// configureSystemServer serves the Prometheus metrics on the address while the application runs
+func configureSystemServer(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner, address string) *echo.Echo {
+	s := echo.New()
+	s.HideBanner = true
+	s.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				if err := s.Start(address); err != nil && err != http.ErrServerClosed {
+					zap.L().Error("failed to start system server", zap.Error(err))
+					if err = shutdowner.Shutdown(); err != nil {
+						zap.L().Error("fx shutdown error", zap.Error(err))
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return s.Shutdown(ctx)
+		},
+	})
+	return s
+}
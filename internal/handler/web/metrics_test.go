@@ -0,0 +1,73 @@
+package web
+
+import (
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/fx/fxtest"
+)
+
+// failingWebhookService fails the import with a retryable error and every other operation with a client error
+type failingWebhookService struct {
+	echoWebhookService
+}
+
+func (failingWebhookService) HandleImportCertificates(echo.Context) error {
+	return &domain.OperationError{Status: http.StatusBadRequest, Code: domain.ErrorCodeCAUnavailable, Message: "unavailable", Retryable: true}
+}
+
+func (failingWebhookService) HandleCheckOrder(echo.Context) error {
+	return &domain.OperationError{Status: http.StatusBadRequest, Code: domain.ErrorCodeOperationFailed, Message: "failed"}
+}
+
+func TestHookMetrics(t *testing.T) {
+	e := newEcho()
+	config := PayloadEncryptionConfig{KeyPath: filepath.Join(t.TempDir(), "missing.pem"), AllowPlaintext: true}
+	require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, failingWebhookService{}, config))
+
+	tests := []struct {
+		name              string
+		hook              string
+		expectedStatus    int
+		expectedRetryable bool
+	}{
+		{name: "success", hook: "testconnection", expectedStatus: http.StatusOK},
+		{name: "failure", hook: "checkorder", expectedStatus: http.StatusBadRequest},
+		{name: "retryableFailure", hook: "importcertificates", expectedStatus: http.StatusBadRequest, expectedRetryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := strconv.Itoa(tt.expectedStatus/100) + "xx"
+			requests := testutil.ToFloat64(hookRequests.WithLabelValues(tt.hook, status))
+			retryable := testutil.ToFloat64(hookRetryableErrors.WithLabelValues(tt.hook))
+
+			recorder := serve(e, http.MethodPost, "/v1/"+tt.hook, `{"connection":{}}`)
+			require.Equal(t, tt.expectedStatus, recorder.Code)
+			require.Equal(t, requests+1, testutil.ToFloat64(hookRequests.WithLabelValues(tt.hook, status)))
+
+			expectedRetryable := retryable
+			if tt.expectedRetryable {
+				expectedRetryable++
+			}
+			require.Equal(t, expectedRetryable, testutil.ToFloat64(hookRetryableErrors.WithLabelValues(tt.hook)))
+		})
+	}
+}
+
+func TestPayloadDecryptionFailureMetrics(t *testing.T) {
+	_, path := generateKey(t)
+	e := newEcho()
+	require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, PayloadEncryptionConfig{KeyPath: path}))
+
+	failures := testutil.ToFloat64(payloadDecryptionFailures.WithLabelValues(decryptionFailureInvalidJWE))
+	recorder := serve(e, http.MethodPost, "/v1/testconnection", `{"connection":{}}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Equal(t, failures+1, testutil.ToFloat64(payloadDecryptionFailures.WithLabelValues(decryptionFailureInvalidJWE)))
+}
@@ -0,0 +1,401 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	keyTypeRSA = "RSA"
+	keyTypeEC  = "EC"
+
+	headerContentEncryption = jose.HeaderKey("enc")
+
+	// DefaultPayloadKeyReloadInterval is how often the payload encryption key files are checked for changes
+	DefaultPayloadKeyReloadInterval = 30 * time.Second
+	// DefaultPayloadKeyGracePeriod is how long the replaced payload encryption keys remain accepted after a reload
+	DefaultPayloadKeyGracePeriod = 5 * time.Minute
+)
+
+// supportedKeyAlgorithms lists the JWE key management algorithms accepted for the payloads and the type
+// of key each of them needs. RSA1_5 and the symmetric algorithms are left out on purpose
+var supportedKeyAlgorithms = map[jose.KeyAlgorithm]string{
+	jose.RSA_OAEP:       keyTypeRSA,
+	jose.RSA_OAEP_256:   keyTypeRSA,
+	jose.ECDH_ES:        keyTypeEC,
+	jose.ECDH_ES_A128KW: keyTypeEC,
+	jose.ECDH_ES_A192KW: keyTypeEC,
+	jose.ECDH_ES_A256KW: keyTypeEC,
+}
+
+// supportedContentEncryptions lists the JWE content encryption algorithms accepted for the payloads
+var supportedContentEncryptions = map[jose.ContentEncryption]bool{
+	jose.A128GCM:       true,
+	jose.A192GCM:       true,
+	jose.A256GCM:       true,
+	jose.A128CBC_HS256: true,
+	jose.A192CBC_HS384: true,
+	jose.A256CBC_HS512: true,
+}
+
+// payloadKey is a private key the payloads may be encrypted for, identified by the name of its file
+type payloadKey struct {
+	id          string
+	keyType     string
+	key         crypto.PrivateKey
+	fingerprint string
+}
+
+// payloadKeyGeneration holds keys replaced by a reload, which are accepted until the end of their grace period
+type payloadKeyGeneration struct {
+	keys  []payloadKey
+	until time.Time
+}
+
+// payloadKeySet holds the current keys and the generations of keys they replaced, the most recent first
+type payloadKeySet struct {
+	keys     []payloadKey
+	previous []payloadKeyGeneration
+}
+
+// payloadDecrypter decrypts JWE payloads with the first of its keys that fits. The keys can be replaced
+// while requests are being decrypted
+type payloadDecrypter struct {
+	keySet atomic.Pointer[payloadKeySet]
+	now    func() time.Time
+}
+
+func newPayloadDecrypter(keys []payloadKey) *payloadDecrypter {
+	d := &payloadDecrypter{now: time.Now}
+	d.keySet.Store(&payloadKeySet{keys: keys})
+	return d
+}
+
+// replace swaps in new keys, keeping the current ones valid during the grace period. The generations replaced
+// earlier remain valid until the end of their own grace period, the expired ones are dropped
+func (d *payloadDecrypter) replace(keys []payloadKey, grace time.Duration) {
+	now := d.now()
+	current := d.keySet.Load()
+	previous := []payloadKeyGeneration{{keys: current.keys, until: now.Add(grace)}}
+	for _, generation := range current.previous {
+		if now.Before(generation.until) {
+			previous = append(previous, generation)
+		}
+	}
+	d.keySet.Store(&payloadKeySet{
+		keys:     keys,
+		previous: previous,
+	})
+}
+
+// activeKeys returns the current keys followed by the replaced keys still in their grace period
+func (d *payloadDecrypter) activeKeys() []payloadKey {
+	set := d.keySet.Load()
+	now := d.now()
+	keys := append([]payloadKey{}, set.keys...)
+	for _, generation := range set.previous {
+		if now.Before(generation.until) {
+			keys = append(keys, generation.keys...)
+		}
+	}
+	return keys
+}
+
+// loadPayloadKeys loads the payload encryption key in the PEM file at path, or every PEM file when path is a directory
+func loadPayloadKeys(path string) ([]payloadKey, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("payload encryption key not found or readable: %w", err)
+	}
+	if !info.IsDir() {
+		key, err := loadPayloadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return []payloadKey{*key}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("payload encryption key not found or readable: %w", err)
+	}
+	var keys []payloadKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		key, err := loadPayloadKey(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("payload encryption key not found or readable: no PEM file in %s", path)
+	}
+	return keys, nil
+}
+
+func loadPayloadKey(path string) (*payloadKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("payload encryption key not found or readable: %w", err)
+	}
+	key, keyType, err := parsePayloadKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	fingerprint, err := publicKeyFingerprint(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &payloadKey{
+		id:          strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		keyType:     keyType,
+		key:         key,
+		fingerprint: fingerprint,
+	}, nil
+}
+
+// publicKeyFingerprint is the hex SHA-256 of the DER encoded public key, so keys can be told apart in logs
+func publicKeyFingerprint(key crypto.PrivateKey) (string, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", fmt.Errorf("payload encryption key of type %T has no public key", key)
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", fmt.Errorf("payload encryption public key not encodable: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func fingerprints(keys []payloadKey) []string {
+	var result []string
+	for _, key := range keys {
+		result = append(result, key.fingerprint)
+	}
+	return result
+}
+
+// parsePayloadKey parses a PKCS#1 RSA, SEC 1 EC or PKCS#8 RSA or EC private key
+func parsePayloadKey(data []byte) (crypto.PrivateKey, string, error) {
+	p, _ := pem.Decode(data)
+	if p == nil {
+		return nil, "", errors.New("payload encryption key not in PEM format")
+	}
+
+	var key interface{}
+	var err error
+	switch p.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(p.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(p.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(p.Bytes)
+	default:
+		return nil, "", fmt.Errorf("payload encryption key has unsupported PEM type %q", p.Type)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("payload encryption key not properly encoded: %w", err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, keyTypeRSA, nil
+	case *ecdsa.PrivateKey:
+		return k, keyTypeEC, nil
+	default:
+		return nil, "", fmt.Errorf("payload encryption key of type %T is not supported", key)
+	}
+}
+
+// candidates returns the keys of the right type, those named by kid first. A replaced key may share its
+// name with the current one, so the other keys remain candidates
+func (d *payloadDecrypter) candidates(kid string, keyType string) []payloadKey {
+	var named, others []payloadKey
+	for _, key := range d.activeKeys() {
+		if key.keyType != keyType {
+			continue
+		}
+		if kid != "" && key.id == kid {
+			named = append(named, key)
+		} else {
+			others = append(others, key)
+		}
+	}
+	return append(named, others...)
+}
+
+// decrypt checks the algorithms of the JWE payload against the allowlists and decrypts it
+func (d *payloadDecrypter) decrypt(payload []byte) ([]byte, error) {
+	object, err := jose.ParseEncrypted(string(payload))
+	if err != nil {
+		payloadDecryptionFailures.WithLabelValues(decryptionFailureInvalidJWE).Inc()
+		return nil, fmt.Errorf("payload is not a valid JWE: %w", err)
+	}
+
+	alg := jose.KeyAlgorithm(object.Header.Algorithm)
+	keyType, ok := supportedKeyAlgorithms[alg]
+	if !ok {
+		payloadDecryptionFailures.WithLabelValues(decryptionFailureUnsupportedAlgorithm).Inc()
+		return nil, fmt.Errorf("payload key algorithm %q is not supported", alg)
+	}
+	enc, _ := object.Header.ExtraHeaders[headerContentEncryption].(string)
+	if !supportedContentEncryptions[jose.ContentEncryption(enc)] {
+		payloadDecryptionFailures.WithLabelValues(decryptionFailureUnsupportedAlgorithm).Inc()
+		return nil, fmt.Errorf("payload content encryption %q is not supported", enc)
+	}
+
+	for _, key := range d.candidates(object.Header.KeyID, keyType) {
+		decrypted, err := object.Decrypt(key.key)
+		if err == nil {
+			return decrypted, nil
+		}
+		zap.L().Debug("payload not decrypted with key", zap.String("kid", key.id), zap.Error(err))
+	}
+	payloadDecryptionFailures.WithLabelValues(decryptionFailureNoMatchingKey).Inc()
+	return nil, errors.New("payload could not be decrypted with any payload encryption key")
+}
+
+func addPayloadEncryptionMiddleware(g *echo.Group, decrypter *payloadDecrypter) {
+	zap.L().Info("adding payload encryption middleware", zap.Strings("fingerprints", fingerprints(decrypter.activeKeys())))
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				payloadDecryptionFailures.WithLabelValues(decryptionFailureRead).Inc()
+				return invalidPayloadError(fmt.Errorf("failed to read payload: %w", err))
+			}
+			decrypted, err := decrypter.decrypt(body)
+			if err != nil {
+				logging.FromContext(req.Context()).Error("failed to decrypt payload", zap.Error(err))
+				return invalidPayloadError(err)
+			}
+			req.Body = io.NopCloser(bytes.NewReader(decrypted))
+			return next(c)
+		}
+	})
+}
+
+// payloadKeyWatcher reloads the payload encryption keys when the files at path change
+type payloadKeyWatcher struct {
+	path      string
+	grace     time.Duration
+	decrypter *payloadDecrypter
+	version   string
+}
+
+// watchPayloadKeys polls the key files at the configured interval while the application runs
+func watchPayloadKeys(lifecycle fx.Lifecycle, config PayloadEncryptionConfig, decrypter *payloadDecrypter) {
+	w := &payloadKeyWatcher{
+		path:      config.KeyPath,
+		grace:     config.GracePeriod,
+		decrypter: decrypter,
+	}
+	w.version, _ = payloadKeyFilesVersion(config.KeyPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(config.ReloadInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						w.check()
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+}
+
+// check reloads the keys if the files changed since the last check. A failed reload keeps the current keys
+func (w *payloadKeyWatcher) check() {
+	version, err := payloadKeyFilesVersion(w.path)
+	if err == nil && version == w.version {
+		return
+	}
+	var keys []payloadKey
+	if err == nil {
+		keys, err = loadPayloadKeys(w.path)
+	}
+	if err != nil {
+		// only retry once the files change again, the error would otherwise be reported at every check
+		w.version = version
+		payloadKeyReloads.WithLabelValues("failure").Inc()
+		zap.L().Error("failed to reload payload encryption keys, keeping the current keys", zap.String("path", w.path), zap.Error(err))
+		return
+	}
+
+	w.decrypter.replace(keys, w.grace)
+	w.version = version
+	payloadKeyReloads.WithLabelValues("success").Inc()
+	zap.L().Info("payload encryption keys reloaded", zap.String("path", w.path),
+		zap.Strings("fingerprints", fingerprints(keys)), zap.Duration("previousKeysGracePeriod", w.grace))
+}
+
+// payloadKeyFilesVersion summarises the name, size and modification time of the key files, following
+// symbolic links so that the atomic swaps of mounted secrets are noticed
+func payloadKeyFilesVersion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano()), nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var version strings.Builder
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&version, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return version.String(), nil
+}
@@ -0,0 +1,314 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func encodeKey(t *testing.T, key interface{}, pkcs8 bool) []byte {
+	if pkcs8 {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	}
+	t.Fatalf("unexpected key type %T", key)
+	return nil
+}
+
+func encryptPayload(t *testing.T, alg jose.KeyAlgorithm, enc jose.ContentEncryption, key interface{}, kid string) []byte {
+	encrypter, err := jose.NewEncrypter(enc, jose.Recipient{Algorithm: alg, Key: key, KeyID: kid}, nil)
+	require.NoError(t, err)
+	object, err := encrypter.Encrypt([]byte(`{"connection":{}}`))
+	require.NoError(t, err)
+	serialized, err := object.CompactSerialize()
+	require.NoError(t, err)
+	return []byte(serialized)
+}
+
+func TestParsePayloadKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		expectedType  string
+		expectedError string
+	}{
+		{name: "pkcs1", data: encodeKey(t, rsaKey, false), expectedType: keyTypeRSA},
+		{name: "pkcs8RSA", data: encodeKey(t, rsaKey, true), expectedType: keyTypeRSA},
+		{name: "sec1EC", data: encodeKey(t, ecKey, false), expectedType: keyTypeEC},
+		{name: "pkcs8EC", data: encodeKey(t, ecKey, true), expectedType: keyTypeEC},
+		{name: "pkcs8Ed25519", data: encodeKey(t, edKey, true), expectedError: "payload encryption key of type ed25519.PrivateKey is not supported"},
+		{name: "certificate", data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")}), expectedError: `payload encryption key has unsupported PEM type "CERTIFICATE"`},
+		{name: "notPEM", data: []byte("not a key"), expectedError: "payload encryption key not in PEM format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, keyType, err := parsePayloadKey(tt.data)
+			if tt.expectedError != "" {
+				require.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, key)
+			require.Equal(t, tt.expectedType, keyType)
+		})
+	}
+}
+
+func TestPayloadDecrypter(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	stranger, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.pem"), encodeKey(t, current, true), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "previous.pem"), encodeKey(t, previous, false), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ec.pem"), encodeKey(t, ecKey, false), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0600))
+
+	keys, err := loadPayloadKeys(dir)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	decrypter := newPayloadDecrypter(keys)
+
+	tests := []struct {
+		name          string
+		payload       []byte
+		expectedError string
+	}{
+		{name: "kid", payload: encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &current.PublicKey, "current")},
+		{name: "previousKid", payload: encryptPayload(t, jose.RSA_OAEP, jose.A128CBC_HS256, &previous.PublicKey, "previous")},
+		{name: "noKid", payload: encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &previous.PublicKey, "")},
+		{name: "unknownKid", payload: encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &current.PublicKey, "other")},
+		{name: "ec", payload: encryptPayload(t, jose.ECDH_ES_A256KW, jose.A256GCM, &ecKey.PublicKey, "")},
+		{
+			name:          "rsa15",
+			payload:       encryptPayload(t, jose.RSA1_5, jose.A256GCM, &current.PublicKey, "current"),
+			expectedError: `payload key algorithm "RSA1_5" is not supported`,
+		},
+		{
+			name:          "unknownKey",
+			payload:       encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &stranger.PublicKey, "current"),
+			expectedError: "payload could not be decrypted with any payload encryption key",
+		},
+		{
+			name:          "notJWE",
+			payload:       []byte(`{"connection":{}}`),
+			expectedError: "payload is not a valid JWE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decrypted, err := decrypter.decrypt(tt.payload)
+			if tt.expectedError != "" {
+				require.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, `{"connection":{}}`, string(decrypted))
+		})
+	}
+}
+
+func TestLoadPayloadKeys(t *testing.T) {
+	t.Run("emptyDirectory", func(t *testing.T) {
+		_, err := loadPayloadKeys(t.TempDir())
+		require.ErrorContains(t, err, "no PEM file in")
+	})
+
+	t.Run("malformedFileInDirectory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("broken"), 0600))
+		_, err := loadPayloadKeys(dir)
+		require.ErrorContains(t, err, "broken.pem: payload encryption key not in PEM format")
+	})
+
+	t.Run("fileIdentifiedByName", func(t *testing.T) {
+		_, path := generateKey(t)
+		keys, err := loadPayloadKeys(path)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		require.Equal(t, "payload-encryption-key", keys[0].id)
+	})
+}
+
+func TestPayloadKeyWatcher(t *testing.T) {
+	first, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "payload-encryption-key.pem")
+	writeVersion := func(data []byte, version int) {
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		// make every version visible even on filesystems with a coarse modification time
+		modTime := time.Unix(int64(1700000000+version), 0)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	writeVersion(encodeKey(t, first, false), 0)
+
+	keys, err := loadPayloadKeys(dir)
+	require.NoError(t, err)
+	now := time.Now()
+	decrypter := newPayloadDecrypter(keys)
+	decrypter.now = func() time.Time { return now }
+
+	watcher := &payloadKeyWatcher{path: dir, grace: time.Minute, decrypter: decrypter}
+	watcher.version, err = payloadKeyFilesVersion(dir)
+	require.NoError(t, err)
+
+	fromFirst := encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &first.PublicKey, "payload-encryption-key")
+	fromSecond := encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &second.PublicKey, "payload-encryption-key")
+	successes := testutil.ToFloat64(payloadKeyReloads.WithLabelValues("success"))
+	failures := testutil.ToFloat64(payloadKeyReloads.WithLabelValues("failure"))
+
+	t.Run("unchanged", func(t *testing.T) {
+		watcher.check()
+		require.Equal(t, successes, testutil.ToFloat64(payloadKeyReloads.WithLabelValues("success")))
+		_, err := decrypter.decrypt(fromSecond)
+		require.Error(t, err)
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		writeVersion(encodeKey(t, second, true), 1)
+		watcher.check()
+		require.Equal(t, successes+1, testutil.ToFloat64(payloadKeyReloads.WithLabelValues("success")))
+
+		_, err := decrypter.decrypt(fromSecond)
+		require.NoError(t, err)
+		_, err = decrypter.decrypt(fromFirst)
+		require.NoError(t, err, "the previous key is valid during the grace period")
+	})
+
+	t.Run("malformed keeps last good key", func(t *testing.T) {
+		writeVersion([]byte("truncated"), 2)
+		watcher.check()
+		watcher.check()
+		require.Equal(t, failures+1, testutil.ToFloat64(payloadKeyReloads.WithLabelValues("failure")))
+
+		_, err := decrypter.decrypt(fromSecond)
+		require.NoError(t, err)
+	})
+
+	t.Run("grace period over", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		_, err := decrypter.decrypt(fromFirst)
+		require.ErrorContains(t, err, "payload could not be decrypted with any payload encryption key")
+		_, err = decrypter.decrypt(fromSecond)
+		require.NoError(t, err)
+	})
+}
+
+func TestPayloadDecrypterRotations(t *testing.T) {
+	var keys []*rsa.PrivateKey
+	var payloads [][]byte
+	for i := 0; i < 4; i++ {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		keys = append(keys, key)
+		payloads = append(payloads, encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &key.PublicKey, "payload-encryption-key"))
+	}
+	generation := func(i int) []payloadKey {
+		fingerprint, err := publicKeyFingerprint(keys[i])
+		require.NoError(t, err)
+		return []payloadKey{{id: "payload-encryption-key", keyType: keyTypeRSA, key: keys[i], fingerprint: fingerprint}}
+	}
+
+	now := time.Now()
+	decrypter := newPayloadDecrypter(generation(0))
+	decrypter.now = func() time.Time { return now }
+
+	// two rotations inside the grace period of the first one
+	decrypter.replace(generation(1), time.Minute)
+	now = now.Add(20 * time.Second)
+	decrypter.replace(generation(2), time.Minute)
+	for i := 0; i < 3; i++ {
+		_, err := decrypter.decrypt(payloads[i])
+		require.NoError(t, err, "generation %d is valid during its grace period", i)
+	}
+
+	// the first generation expires before the second one
+	now = now.Add(45 * time.Second)
+	_, err := decrypter.decrypt(payloads[0])
+	require.Error(t, err)
+	for i := 1; i < 3; i++ {
+		_, err := decrypter.decrypt(payloads[i])
+		require.NoError(t, err, "generation %d is valid during its grace period", i)
+	}
+
+	// the expired generations are dropped on the next reload
+	decrypter.replace(generation(3), time.Minute)
+	require.Len(t, decrypter.keySet.Load().previous, 2)
+	now = now.Add(time.Minute)
+	decrypter.replace(generation(0), time.Minute)
+	require.Len(t, decrypter.keySet.Load().previous, 1)
+	_, err = decrypter.decrypt(payloads[3])
+	require.NoError(t, err)
+	for i := 1; i < 3; i++ {
+		_, err := decrypter.decrypt(payloads[i])
+		require.Error(t, err)
+	}
+}
+
+func TestWatchPayloadKeys(t *testing.T) {
+	first, path := generateKey(t)
+	keys, err := loadPayloadKeys(path)
+	require.NoError(t, err)
+	decrypter := newPayloadDecrypter(keys)
+
+	lifecycle := fxtest.NewLifecycle(t)
+	watchPayloadKeys(lifecycle, PayloadEncryptionConfig{KeyPath: path, ReloadInterval: 10 * time.Millisecond, GracePeriod: time.Minute}, decrypter)
+	lifecycle.RequireStart()
+	defer lifecycle.RequireStop()
+
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, encodeKey(t, second, false), 0600))
+	modTime := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	fromSecond := encryptPayload(t, jose.ECDH_ES, jose.A128GCM, &second.PublicKey, "")
+	require.Eventually(t, func() bool {
+		_, err := decrypter.decrypt(fromSecond)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = decrypter.decrypt(encryptPayload(t, jose.RSA_OAEP, jose.A256GCM, &first.PublicKey, ""))
+	require.NoError(t, err)
+}
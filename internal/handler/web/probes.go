@@ -0,0 +1,185 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// DefaultDigiCertCheckTimeout bounds the deep readiness check of the DigiCert API
+const DefaultDigiCertCheckTimeout = 5 * time.Second
+
+// DefaultDigiCertCheckInterval is how often the deep readiness check reaches the DigiCert API
+const DefaultDigiCertCheckInterval = 30 * time.Second
+
+// Probe statuses
+const (
+	probeStatusOK          = "OK"
+	probeStatusUnavailable = "UNAVAILABLE"
+	probeStatusStarting    = "starting"
+	probeStatusDraining    = "draining"
+	probeStatusNotChecked  = "not checked yet"
+)
+
+// ProbeConfig controls the readiness probe
+type ProbeConfig struct {
+	// DigiCertURL is the DigiCert API base URL reached, without credentials, by the deep readiness check.
+	// Without it the check is skipped
+	DigiCertURL string `yaml:"digicertUrl" env:"READINESS_DIGICERT_URL"`
+	// CheckTimeout bounds the deep readiness check
+	CheckTimeout time.Duration `yaml:"checkTimeout" env:"READINESS_CHECK_TIMEOUT"`
+	// CheckInterval is how often the deep readiness check runs in the background, the readiness probe only
+	// reporting the outcome of the last one
+	CheckInterval time.Duration `yaml:"checkInterval" env:"READINESS_CHECK_INTERVAL"`
+	// DrainDelay is how long the connector keeps serving once it reports itself not ready on shutdown, for
+	// the traffic to be routed away first
+	DrainDelay time.Duration `yaml:"drainDelay" env:"READINESS_DRAIN_DELAY"`
+}
+
+// ProbeResponse is the body returned by the liveness and readiness endpoints
+type ProbeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// readiness tells whether the connector can take requests
+type readiness struct {
+	started  atomic.Bool
+	draining atomic.Bool
+	client   *http.Client
+	url      string
+	interval time.Duration
+	// digicert is the outcome of the last DigiCert check
+	digicert atomic.Value
+}
+
+// RegisterProbes registers the liveness and readiness endpoints. It is invoked once the handlers are
+// registered, which fails the start when the payload keys or the configuration are not usable, and the
+// connector only reports itself ready once started. The DigiCert check runs in the background while started
+func RegisterProbes(lifecycle fx.Lifecycle, e *echo.Echo, config ProbeConfig) {
+	r := &readiness{url: config.DigiCertURL, interval: config.CheckInterval}
+	if r.url != "" {
+		timeout := config.CheckTimeout
+		if timeout <= 0 {
+			timeout = DefaultDigiCertCheckTimeout
+		}
+		if r.interval <= 0 {
+			r.interval = DefaultDigiCertCheckInterval
+		}
+		r.client = &http.Client{Timeout: timeout}
+		r.digicert.Store(probeStatusNotChecked)
+	}
+	stopMonitor := func() {}
+
+	e.GET("/livez", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, ProbeResponse{Status: probeStatusOK})
+	})
+	e.GET("/readyz", r.handle)
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if r.client != nil {
+				monitorCtx, cancel := context.WithCancel(context.Background())
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					r.monitorDigiCert(monitorCtx)
+				}()
+				stopMonitor = func() {
+					cancel()
+					<-done
+				}
+			}
+			r.started.Store(true)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			stopMonitor()
+			r.draining.Store(true)
+			zap.L().Info("draining, reporting not ready", zap.Duration("delay", config.DrainDelay))
+			select {
+			case <-time.After(config.DrainDelay):
+			case <-ctx.Done():
+			}
+			return nil
+		},
+	})
+}
+
+func (r *readiness) handle(c echo.Context) error {
+	response := ProbeResponse{Status: probeStatusOK, Checks: map[string]string{}}
+	fail := func(check string, status string) {
+		response.Status = probeStatusUnavailable
+		response.Checks[check] = status
+	}
+
+	switch {
+	case r.draining.Load():
+		fail("lifecycle", probeStatusDraining)
+	case !r.started.Load():
+		fail("lifecycle", probeStatusStarting)
+	default:
+		response.Checks["lifecycle"] = probeStatusOK
+	}
+
+	if r.client != nil {
+		if status := r.digicert.Load().(string); status != probeStatusOK {
+			fail("digicert", status)
+		} else {
+			response.Checks["digicert"] = probeStatusOK
+		}
+	}
+
+	if response.Status != probeStatusOK {
+		return c.JSON(http.StatusServiceUnavailable, response)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// monitorDigiCert checks the DigiCert API right away and then every interval until ctx is done, so that the
+// probes of every replica do not all reach DigiCert each time they are called
+func (r *readiness) monitorDigiCert(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		status := probeStatusOK
+		if err := r.checkDigiCert(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			zap.L().Warn("DigiCert readiness check failed", zap.String("url", r.url), zap.Error(err))
+			status = err.Error()
+		}
+		r.digicert.Store(status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDigiCert reaches the DigiCert API. Any answer but a server error will do, the request having no
+// credentials it is expected to be refused
+func (r *readiness) checkDigiCert(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("DigiCert answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+)
+
+func requireProbe(t *testing.T, e *echo.Echo, path string, expectedStatus int, expected ProbeResponse) {
+	recorder := serve(e, http.MethodGet, path, "")
+	require.Equal(t, expectedStatus, recorder.Code)
+
+	response := ProbeResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, expected, response)
+}
+
+// requireEventually waits for the readiness probe to give the expected answer, the DigiCert check running
+// in the background
+func requireEventually(t *testing.T, e *echo.Echo, expectedStatus int, expected ProbeResponse) {
+	require.Eventually(t, func() bool {
+		recorder := serve(e, http.MethodGet, "/readyz", "")
+		response := ProbeResponse{}
+		return recorder.Code == expectedStatus && json.Unmarshal(recorder.Body.Bytes(), &response) == nil &&
+			reflect.DeepEqual(expected, response)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRegisterProbes(t *testing.T) {
+	t.Run("lifecycle", func(t *testing.T) {
+		e := newEcho()
+		lifecycle := fxtest.NewLifecycle(t)
+		RegisterProbes(lifecycle, e, ProbeConfig{})
+
+		requireProbe(t, e, "/livez", http.StatusOK, ProbeResponse{Status: "OK"})
+		requireProbe(t, e, "/readyz", http.StatusServiceUnavailable, ProbeResponse{Status: "UNAVAILABLE", Checks: map[string]string{"lifecycle": "starting"}})
+
+		lifecycle.RequireStart()
+		requireProbe(t, e, "/readyz", http.StatusOK, ProbeResponse{Status: "OK", Checks: map[string]string{"lifecycle": "OK"}})
+
+		lifecycle.RequireStop()
+		requireProbe(t, e, "/readyz", http.StatusServiceUnavailable, ProbeResponse{Status: "UNAVAILABLE", Checks: map[string]string{"lifecycle": "draining"}})
+		requireProbe(t, e, "/livez", http.StatusOK, ProbeResponse{Status: "OK"})
+	})
+
+	t.Run("drain delay", func(t *testing.T) {
+		lifecycle := fxtest.NewLifecycle(t)
+		RegisterProbes(lifecycle, newEcho(), ProbeConfig{DrainDelay: 50 * time.Millisecond})
+		lifecycle.RequireStart()
+
+		start := time.Now()
+		lifecycle.RequireStop()
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("digicert", func(t *testing.T) {
+		var status atomic.Int32
+		status.Store(http.StatusUnauthorized)
+		digicert := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Empty(t, r.Header.Get("X-DC-DEVKEY"))
+			w.WriteHeader(int(status.Load()))
+		}))
+		defer digicert.Close()
+
+		e := newEcho()
+		lifecycle := fxtest.NewLifecycle(t)
+		RegisterProbes(lifecycle, e, ProbeConfig{DigiCertURL: digicert.URL, CheckInterval: 10 * time.Millisecond})
+		requireProbe(t, e, "/readyz", http.StatusServiceUnavailable, ProbeResponse{
+			Status: "UNAVAILABLE",
+			Checks: map[string]string{"lifecycle": "starting", "digicert": "not checked yet"},
+		})
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+
+		requireEventually(t, e, http.StatusOK, ProbeResponse{Status: "OK", Checks: map[string]string{"lifecycle": "OK", "digicert": "OK"}})
+
+		status.Store(http.StatusBadGateway)
+		requireEventually(t, e, http.StatusServiceUnavailable, ProbeResponse{
+			Status: "UNAVAILABLE",
+			Checks: map[string]string{"lifecycle": "OK", "digicert": "DigiCert answered 502 Bad Gateway"},
+		})
+
+		status.Store(http.StatusUnauthorized)
+		requireEventually(t, e, http.StatusOK, ProbeResponse{Status: "OK", Checks: map[string]string{"lifecycle": "OK", "digicert": "OK"}})
+	})
+
+	t.Run("digicert cached", func(t *testing.T) {
+		var checks atomic.Int32
+		digicert := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checks.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer digicert.Close()
+
+		e := newEcho()
+		lifecycle := fxtest.NewLifecycle(t)
+		RegisterProbes(lifecycle, e, ProbeConfig{DigiCertURL: digicert.URL, CheckInterval: time.Hour})
+		lifecycle.RequireStart()
+		defer lifecycle.RequireStop()
+
+		requireEventually(t, e, http.StatusOK, ProbeResponse{Status: "OK", Checks: map[string]string{"lifecycle": "OK", "digicert": "OK"}})
+		for i := 0; i < 5; i++ {
+			requireProbe(t, e, "/readyz", http.StatusOK, ProbeResponse{Status: "OK", Checks: map[string]string{"lifecycle": "OK", "digicert": "OK"}})
+		}
+		require.Equal(t, int32(1), checks.Load())
+	})
+}
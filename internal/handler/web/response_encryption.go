@@ -0,0 +1,159 @@
+package web
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/venafi/digicert-ca-connector/internal/app/logging"
+	"go.uber.org/zap"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// MIMEApplicationJOSE is the media type of JWE compact serialized bodies, clients ask for encrypted
+// responses by accepting it
+const MIMEApplicationJOSE = "application/jose"
+
+// responseEncrypter encrypts the responses for the recipient public key
+type responseEncrypter struct {
+	encrypter jose.Encrypter
+	always    bool
+}
+
+// loadResponseKey loads the recipient RSA or EC public key from a PEM public key or certificate
+func loadResponseKey(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("response encryption key not found or readable: %w", err)
+	}
+	p, _ := pem.Decode(data)
+	if p == nil {
+		return nil, errors.New("response encryption key not in PEM format")
+	}
+
+	var key interface{}
+	switch p.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(p.Bytes)
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		certificate, err = x509.ParseCertificate(p.Bytes)
+		if err == nil {
+			key = certificate.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("response encryption key has unsupported PEM type %q", p.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("response encryption key not properly encoded: %w", err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("response encryption key of type %T is not supported", key)
+	}
+}
+
+// newResponseEncrypter encrypts with RSA-OAEP-256 or ECDH-ES+A256KW, depending on the key, and A256GCM
+func newResponseEncrypter(key interface{}, always bool) (*responseEncrypter, error) {
+	alg := jose.RSA_OAEP_256
+	if _, ok := key.(*ecdsa.PublicKey); ok {
+		alg = jose.ECDH_ES_A256KW
+	}
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: alg, Key: key},
+		(&jose.EncrypterOptions{}).WithContentType(echo.MIMEApplicationJSON))
+	if err != nil {
+		return nil, fmt.Errorf("response encrypter not created: %w", err)
+	}
+	return &responseEncrypter{encrypter: encrypter, always: always}, nil
+}
+
+// wanted tells whether the response to the request is to be encrypted
+func (re *responseEncrypter) wanted(req *http.Request) bool {
+	return re.always || acceptsJOSE(req)
+}
+
+func (re *responseEncrypter) encrypt(body []byte) (string, error) {
+	object, err := re.encrypter.Encrypt(body)
+	if err != nil {
+		return "", err
+	}
+	return object.CompactSerialize()
+}
+
+func acceptsJOSE(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get(echo.HeaderAccept), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), MIMEApplicationJOSE) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedResponseWriter holds back the response so that it can be encrypted as a whole
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// Flush is a no-op, the buffered response is only written once encrypted
+func (w *bufferedResponseWriter) Flush() {}
+
+// addResponseEncryptionMiddleware encrypts the responses, errors included, when the configuration or the
+// request asks for it
+func addResponseEncryptionMiddleware(g *echo.Group, re *responseEncrypter) {
+	zap.L().Info("adding response encryption middleware", zap.Bool("always", re.always))
+	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !re.wanted(c.Request()) {
+				return next(c)
+			}
+
+			res := c.Response()
+			original := res.Writer
+			buffered := &bufferedResponseWriter{ResponseWriter: original, status: http.StatusOK}
+			res.Writer = buffered
+			err := next(c)
+			if err != nil {
+				// let the error handler render the error while the response is still buffered
+				c.Error(err)
+			}
+			res.Writer = original
+
+			serialized, err := re.encrypt(buffered.body.Bytes())
+			if err != nil {
+				// the response is committed already, and must not go out in plaintext
+				logging.FromContext(c.Request().Context()).Error("failed to encrypt response", zap.Error(err))
+				original.WriteHeader(http.StatusInternalServerError)
+				return nil
+			}
+
+			original.Header().Set(echo.HeaderContentType, MIMEApplicationJOSE)
+			original.Header().Del(echo.HeaderContentLength)
+			original.WriteHeader(buffered.status)
+			n, err := original.Write([]byte(serialized))
+			res.Size = int64(n)
+			return err
+		}
+	})
+}
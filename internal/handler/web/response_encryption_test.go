@@ -0,0 +1,108 @@
+package web
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"gopkg.in/square/go-jose.v2"
+)
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "response-encryption-key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return path
+}
+
+func serveEncrypted(e *echo.Echo, body []byte, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/checkcertificate", strings.NewReader(string(body)))
+	if accept != "" {
+		req.Header.Set(echo.HeaderAccept, accept)
+	}
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func decryptResponse(t *testing.T, recorder *httptest.ResponseRecorder, key interface{}) string {
+	require.Equal(t, MIMEApplicationJOSE, recorder.Header().Get(echo.HeaderContentType))
+	object, err := jose.ParseEncrypted(recorder.Body.String())
+	require.NoError(t, err)
+	require.Equal(t, echo.MIMEApplicationJSON, object.Header.ExtraHeaders[jose.HeaderContentType])
+	decrypted, err := object.Decrypt(key)
+	require.NoError(t, err)
+	return string(decrypted)
+}
+
+func TestResponseEncryption(t *testing.T) {
+	connectorKey, keyPath := generateKey(t)
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	clientECKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	request := encryptPayload(t, jose.RSA_OAEP_256, jose.A256GCM, &connectorKey.PublicKey, "")
+	register := func(t *testing.T, config PayloadEncryptionConfig) *echo.Echo {
+		e := newEcho()
+		config.KeyPath = keyPath
+		require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, config))
+		return e
+	}
+
+	t.Run("negotiated", func(t *testing.T) {
+		e := register(t, PayloadEncryptionConfig{ResponseKeyPath: writePublicKey(t, &clientKey.PublicKey)})
+		requireHealth(t, e, true, "negotiated")
+
+		recorder := serveEncrypted(e, request, "application/jose, application/json;q=0.5")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"connection":{}}`, decryptResponse(t, recorder, clientKey))
+
+		recorder = serveEncrypted(e, request, echo.MIMEApplicationJSON)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"connection":{}}`, recorder.Body.String())
+	})
+
+	t.Run("always", func(t *testing.T) {
+		e := register(t, PayloadEncryptionConfig{ResponseKeyPath: writePublicKey(t, &clientECKey.PublicKey), EncryptResponses: true})
+		requireHealth(t, e, true, "always")
+
+		recorder := serveEncrypted(e, request, "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"connection":{}}`, decryptResponse(t, recorder, clientECKey))
+	})
+
+	t.Run("error encrypted", func(t *testing.T) {
+		e := register(t, PayloadEncryptionConfig{ResponseKeyPath: writePublicKey(t, &clientKey.PublicKey), EncryptResponses: true})
+
+		recorder := serveEncrypted(e, []byte(`{"connection":{}}`), "")
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Contains(t, decryptResponse(t, recorder, clientKey), "payload is not a valid JWE")
+	})
+
+	t.Run("required without key", func(t *testing.T) {
+		err := RegisterHandlers(fxtest.NewLifecycle(t), newEcho(), echoWebhookService{}, PayloadEncryptionConfig{KeyPath: keyPath, EncryptResponses: true})
+		require.EqualError(t, err, "response encryption is required but no response encryption key is configured")
+	})
+
+	t.Run("unusable key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "response-encryption-key.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(clientKey)}), 0600))
+		err := RegisterHandlers(fxtest.NewLifecycle(t), newEcho(), echoWebhookService{}, PayloadEncryptionConfig{KeyPath: keyPath, ResponseKeyPath: path})
+		require.ErrorContains(t, err, `response encryption key has unsupported PEM type "RSA PRIVATE KEY"`)
+	})
+}
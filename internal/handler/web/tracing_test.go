@@ -0,0 +1,72 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestTracing(t *testing.T) {
+	savedProvider, savedPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(savedProvider)
+		otel.SetTextMapPropagator(savedPropagator)
+	})
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(tracing.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	e := newEcho()
+	config := PayloadEncryptionConfig{KeyPath: filepath.Join(t.TempDir(), "missing.pem"), AllowPlaintext: true}
+	require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, failingWebhookService{}, config))
+
+	tests := []struct {
+		name           string
+		hook           string
+		traceparent    string
+		expectedStatus codes.Code
+	}{
+		{name: "newTrace", hook: "testconnection", expectedStatus: codes.Unset},
+		{name: "propagated", hook: "testconnection", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectedStatus: codes.Unset},
+		{name: "failure", hook: "checkorder", expectedStatus: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest(http.MethodPost, "/v1/"+tt.hook, strings.NewReader(`{"connection":{}}`))
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			require.Equal(t, "POST /v1/"+tt.hook, span.Name)
+			require.Equal(t, trace.SpanKindServer, span.SpanKind)
+			require.Equal(t, tt.expectedStatus, span.Status.Code)
+			require.Contains(t, span.Attributes, attribute.String("http.route", "/v1/"+tt.hook))
+			if tt.traceparent != "" {
+				require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+				require.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+				require.True(t, span.Parent.IsRemote())
+			} else {
+				require.False(t, span.Parent.IsValid())
+			}
+		})
+	}
+}
@@ -2,21 +2,83 @@
 package web
 
 import (
-	"bytes"
 	"context"
-	"crypto/x509"
-	"encoding/pem"
-	"io"
-	"io/ioutil"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
-	"gopkg.in/square/go-jose.v2"
 )
 
+const (
+	// DefaultPayloadEncryptionKeyPath is where the Satellite mounts the key the request payloads are encrypted for
+	DefaultPayloadEncryptionKeyPath = "/keys/payload-encryption-key.pem"
+	// DefaultAddress is where the HTTP server serving the connector operations listens
+	DefaultAddress = ":8080"
+	// DefaultSystemAddress is where the system HTTP server, serving the metrics, listens
+	DefaultSystemAddress = ":8081"
+)
+
+// ServerConfig controls where the HTTP servers listen
+type ServerConfig struct {
+	Address       string    `yaml:"address" env:"LISTEN_ADDRESS"`
+	SystemAddress string    `yaml:"systemAddress" env:"SYSTEM_LISTEN_ADDRESS"`
+	TLS           TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig holds the PEM certificate and key the connector operations are served with. They are served over
+// plain HTTP when not set
+type TLSConfig struct {
+	CertFile string `yaml:"certFile" env:"TLS_CERT_FILE"`
+	KeyFile  string `yaml:"keyFile" env:"TLS_KEY_FILE"`
+}
+
+// Enabled tells whether the connector operations are served over TLS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// PayloadEncryptionConfig controls the decryption of the payloads sent to the connector operations
+type PayloadEncryptionConfig struct {
+	// KeyPath is the PEM file holding the private key of the payload encryption, or a directory of such files
+	// when several keys are in use during a rotation
+	KeyPath string `yaml:"keyPath" env:"PAYLOAD_ENCRYPTION_KEY_PATH"`
+	// AllowPlaintext lets the operations accept plaintext payloads when the key is missing or unusable,
+	// which is only meant for development
+	AllowPlaintext bool `yaml:"allowPlaintext" env:"ALLOW_PLAINTEXT_PAYLOADS"`
+	// ReloadInterval is how often the key files are checked for changes, no check is done when it is zero
+	ReloadInterval time.Duration `yaml:"reloadInterval" env:"PAYLOAD_ENCRYPTION_KEY_RELOAD_INTERVAL"`
+	// GracePeriod is how long the keys replaced by a reload remain accepted
+	GracePeriod time.Duration `yaml:"gracePeriod" env:"PAYLOAD_ENCRYPTION_KEY_GRACE_PERIOD"`
+	// ResponseKeyPath is the PEM public key or certificate the responses are encrypted for. Without it
+	// the responses are sent in plaintext
+	ResponseKeyPath string `yaml:"responseKeyPath" env:"RESPONSE_ENCRYPTION_KEY_PATH"`
+	// EncryptResponses encrypts every response, otherwise only the requests accepting application/jose get
+	// an encrypted response
+	EncryptResponses bool `yaml:"encryptResponses" env:"ENCRYPT_RESPONSES"`
+}
+
+// HealthResponse is the body returned by the health endpoint
+type HealthResponse struct {
+	Status             string `json:"status"`
+	PayloadEncryption  bool   `json:"payloadEncryption"`
+	ResponseEncryption string `json:"responseEncryption"`
+}
+
+func responseEncryptionMode(re *responseEncrypter) string {
+	switch {
+	case re == nil:
+		return "disabled"
+	case re.always:
+		return "always"
+	default:
+		return "negotiated"
+	}
+}
+
 // WebhookService interfaces for the connector operation functions
 type WebhookService interface {
 	HandleTestConnection(c echo.Context) error
@@ -27,17 +89,28 @@ type WebhookService interface {
 	HandleCheckCertificate(c echo.Context) error
 	HandleImportCertificates(c echo.Context) error
 	HandleRevokeCertificate(c echo.Context) error
+	HandleProcessApproval(c echo.Context) error
+	HandleCheckRevocation(c echo.Context) error
 }
 
 // ConfigureHTTPServers creates an HTTP server with standard middleware and a system HTTP server with health and metrics endpoints
 // returns the echo engine for serving API
-func ConfigureHTTPServers(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner) (*echo.Echo, error) {
+func ConfigureHTTPServers(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner, config ServerConfig) (*echo.Echo, error) {
 	e := echo.New()
+	e.HTTPErrorHandler = HandleError
+	e.Use(requestLogger)
+	configureSystemServer(lifecycle, shutdowner, config.SystemAddress)
 
 	lifecycle.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
-				if err := e.Start(":8080"); err != nil && err != http.ErrServerClosed {
+				var err error
+				if config.TLS.Enabled() {
+					err = e.StartTLS(config.Address, config.TLS.CertFile, config.TLS.KeyFile)
+				} else {
+					err = e.Start(config.Address)
+				}
+				if err != nil && err != http.ErrServerClosed {
 					zap.L().Error("failed to start echo server", zap.Error(err))
 					if err = shutdowner.Shutdown(); err != nil {
 						zap.L().Error("fx shutdown error", zap.Error(err))
@@ -54,14 +127,52 @@ func ConfigureHTTPServers(lifecycle fx.Lifecycle, shutdowner fx.Shutdowner) (*ec
 	return e, nil
 }
 
-// RegisterHandlers adds the method handlers for the supported routes
-func RegisterHandlers(e *echo.Echo, whService WebhookService) error {
+// RegisterHandlers adds the method handlers for the supported routes. Unless plaintext payloads are explicitly
+// allowed, it fails when the payload encryption key cannot be loaded so that the connector does not start
+// accepting credentials in the clear
+func RegisterHandlers(lifecycle fx.Lifecycle, e *echo.Echo, whService WebhookService, config PayloadEncryptionConfig) error {
+	keys, err := loadPayloadKeys(config.KeyPath)
+	if err != nil {
+		if !config.AllowPlaintext {
+			zap.L().Error("payload encryption key not usable", zap.String("path", config.KeyPath), zap.Error(err))
+			return fmt.Errorf("payload encryption is required: %w", err)
+		}
+		zap.L().Warn("payload encryption disabled, plaintext payloads are accepted", zap.String("path", config.KeyPath), zap.Error(err))
+	}
+
+	var re *responseEncrypter
+	if config.ResponseKeyPath != "" {
+		key, err := loadResponseKey(config.ResponseKeyPath)
+		if err == nil {
+			re, err = newResponseEncrypter(key, config.EncryptResponses)
+		}
+		if err != nil {
+			zap.L().Error("response encryption key not usable", zap.String("path", config.ResponseKeyPath), zap.Error(err))
+			return fmt.Errorf("response encryption is configured: %w", err)
+		}
+	} else if config.EncryptResponses {
+		return errors.New("response encryption is required but no response encryption key is configured")
+	}
+
+	health := HealthResponse{Status: "OK", PayloadEncryption: len(keys) > 0, ResponseEncryption: responseEncryptionMode(re)}
 	e.GET("/healthz", func(c echo.Context) error {
-		return c.String(http.StatusOK, "OK")
+		return c.JSON(http.StatusOK, health)
 	})
 
 	g := e.Group("/v1")
-	addPayloadEncryptionMiddleware(g)
+	addTracingMiddleware(g)
+	if re != nil {
+		addResponseEncryptionMiddleware(g, re)
+	}
+	addHookMetricsMiddleware(g)
+	if len(keys) > 0 {
+		decrypter := newPayloadDecrypter(keys)
+		addPayloadEncryptionMiddleware(g, decrypter)
+		if config.ReloadInterval > 0 {
+			watchPayloadKeys(lifecycle, config, decrypter)
+		}
+	}
+	addRequestCaptureMiddleware(g)
 	g.POST("/testconnection", whService.HandleTestConnection)
 	g.POST("/getoptions", whService.HandleGetOptions)
 	g.POST("/validateproduct", whService.HandleValidateProduct)
@@ -70,44 +181,8 @@ func RegisterHandlers(e *echo.Echo, whService WebhookService) error {
 	g.POST("/checkcertificate", whService.HandleCheckCertificate)
 	g.POST("/importcertificates", whService.HandleImportCertificates)
 	g.POST("/revokecertificate", whService.HandleRevokeCertificate)
+	g.POST("/processapproval", whService.HandleProcessApproval)
+	g.POST("/checkrevocation", whService.HandleCheckRevocation)
 
 	return nil
 }
-
-func addPayloadEncryptionMiddleware(g *echo.Group) {
-	privateKeyPemData, err := os.ReadFile("/keys/payload-encryption-key.pem")
-	if err != nil {
-		zap.L().Error("payload encryption key not found or readable", zap.Error(err))
-		return
-	}
-	p, _ := pem.Decode(privateKeyPemData)
-	if p == nil {
-		zap.L().Error("payload encryption key not in PEM format")
-		return
-	}
-	pk, err := x509.ParsePKCS1PrivateKey(p.Bytes)
-	if err != nil {
-		zap.L().Error("payload encryption key not properly encoded", zap.Error(err))
-		return
-	}
-	zap.L().Info("adding payload encryption middleware")
-	g.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			req := c.Request()
-			body, err := ioutil.ReadAll(req.Body)
-			if err != nil {
-				return err
-			}
-			object, err := jose.ParseEncrypted(string(body))
-			if err != nil {
-				return err
-			}
-			decrypted, err := object.Decrypt(pk)
-			if err != nil {
-				return err
-			}
-			req.Body = io.NopCloser(bytes.NewReader(decrypted))
-			return next(c)
-		}
-	})
-}
@@ -0,0 +1,137 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/venafi/digicert-ca-connector/internal/app/domain"
+	"go.uber.org/fx/fxtest"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// echoWebhookService answers every operation with the body it received
+type echoWebhookService struct{}
+
+func (echoWebhookService) echo(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+	return c.String(http.StatusOK, string(body))
+}
+
+func (s echoWebhookService) HandleTestConnection(c echo.Context) error     { return s.echo(c) }
+func (s echoWebhookService) HandleGetOptions(c echo.Context) error         { return s.echo(c) }
+func (s echoWebhookService) HandleValidateProduct(c echo.Context) error    { return s.echo(c) }
+func (s echoWebhookService) HandleRequestCertificate(c echo.Context) error { return s.echo(c) }
+func (s echoWebhookService) HandleCheckOrder(c echo.Context) error         { return s.echo(c) }
+func (s echoWebhookService) HandleCheckCertificate(c echo.Context) error   { return s.echo(c) }
+func (s echoWebhookService) HandleImportCertificates(c echo.Context) error { return s.echo(c) }
+func (s echoWebhookService) HandleRevokeCertificate(c echo.Context) error  { return s.echo(c) }
+func (s echoWebhookService) HandleProcessApproval(c echo.Context) error    { return s.echo(c) }
+func (s echoWebhookService) HandleCheckRevocation(c echo.Context) error    { return s.echo(c) }
+
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = HandleError
+	e.Use(requestLogger)
+	return e
+}
+
+func writeKey(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "payload-encryption-key.pem")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func generateKey(t *testing.T) (*rsa.PrivateKey, string) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return pk, writeKey(t, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)}))
+}
+
+func serve(e *echo.Echo, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func requireHealth(t *testing.T, e *echo.Echo, payloadEncryption bool, responseEncryption string) {
+	recorder := serve(e, http.MethodGet, "/healthz", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	health := HealthResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &health))
+	require.Equal(t, HealthResponse{Status: "OK", PayloadEncryption: payloadEncryption, ResponseEncryption: responseEncryption}, health)
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	t.Run("encrypted payloads", func(t *testing.T) {
+		pk, path := generateKey(t)
+		e := newEcho()
+		require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, PayloadEncryptionConfig{KeyPath: path}))
+		requireHealth(t, e, true, "disabled")
+
+		encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: jose.RSA_OAEP_256, Key: &pk.PublicKey}, nil)
+		require.NoError(t, err)
+		object, err := encrypter.Encrypt([]byte(`{"connection":{}}`))
+		require.NoError(t, err)
+		serialized, err := object.CompactSerialize()
+		require.NoError(t, err)
+
+		recorder := serve(e, http.MethodPost, "/v1/testconnection", serialized)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"connection":{}}`, recorder.Body.String())
+
+		recorder = serve(e, http.MethodPost, "/v1/testconnection", `{"connection":{}}`)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		response := domain.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		require.Equal(t, domain.ErrorCodeInvalidPayload, response.Code)
+		require.False(t, response.Retryable)
+		require.NotEmpty(t, response.CorrelationID)
+	})
+
+	t.Run("missing key strict", func(t *testing.T) {
+		e := newEcho()
+		err := RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, PayloadEncryptionConfig{KeyPath: filepath.Join(t.TempDir(), "missing.pem")})
+		require.ErrorContains(t, err, "payload encryption key not found or readable")
+	})
+
+	t.Run("malformed key strict", func(t *testing.T) {
+		e := newEcho()
+		err := RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, PayloadEncryptionConfig{KeyPath: writeKey(t, []byte("not a key"))})
+		require.ErrorContains(t, err, "payload encryption key not in PEM format")
+	})
+
+	t.Run("unparsable key strict", func(t *testing.T) {
+		e := newEcho()
+		path := writeKey(t, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("garbage")}))
+		err := RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, PayloadEncryptionConfig{KeyPath: path})
+		require.ErrorContains(t, err, "payload encryption key not properly encoded")
+	})
+
+	t.Run("missing key plaintext allowed", func(t *testing.T) {
+		e := newEcho()
+		config := PayloadEncryptionConfig{KeyPath: filepath.Join(t.TempDir(), "missing.pem"), AllowPlaintext: true}
+		require.NoError(t, RegisterHandlers(fxtest.NewLifecycle(t), e, echoWebhookService{}, config))
+		requireHealth(t, e, false, "disabled")
+
+		recorder := serve(e, http.MethodPost, "/v1/testconnection", `{"connection":{}}`)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, `{"connection":{}}`, recorder.Body.String())
+	})
+}